@@ -0,0 +1,146 @@
+package cache
+
+import "time"
+
+// EvictionReason is the cause of an eviction.
+type EvictionReason int
+
+const (
+	// EvictCapacity means the item is evicted since the capacity of the
+	// cache is full.
+	EvictCapacity EvictionReason = iota
+
+	// EvictBudget means the item is evicted since the capacity shared with
+	// the parent or children is exhausted, see NewChild.
+	EvictBudget
+
+	// EvictQuota means the item is evicted since its namespace has reached
+	// its quota, see WithNamespaceQuota.
+	EvictQuota
+
+	// EvictResize means the item is evicted since the cache is shrunk.
+	EvictResize
+
+	// EvictSize means the item is evicted since the total size or cost of
+	// the items exceeds the limit set with WithMaxBytes or WithMaxCost.
+	EvictSize
+
+	// EvictMemory means the item is evicted since the heap is too big, see
+	// WithEmergencyEviction.
+	EvictMemory
+)
+
+// String returns the name of the reason.
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictCapacity:
+		return "capacity"
+	case EvictBudget:
+		return "budget"
+	case EvictQuota:
+		return "quota"
+	case EvictResize:
+		return "resize"
+	case EvictSize:
+		return "size"
+	case EvictMemory:
+		return "memory"
+	default:
+		return "unknown"
+	}
+}
+
+// EvictionRecord describes an eviction decision, with the inputs of the LRU
+// policy that made the item the victim.
+type EvictionRecord struct {
+	// Key is the key of the evicted item.
+	Key interface{}
+
+	// Namespace is the namespace of the evicted item.
+	Namespace string
+
+	// Reason is the cause of the eviction.
+	Reason EvictionReason
+
+	// Time is when the item is evicted.
+	Time time.Time
+
+	// Age is how long the item was in the cache.
+	Age time.Duration
+
+	// Idle is how long the item was not accessed. The least recently used
+	// item is the one idle for the longest time.
+	Idle time.Duration
+
+	// Hits is the number of times the item was retrieved with Get.
+	Hits uint64
+}
+
+// audit is a ring buffer of sampled eviction records.
+type audit struct {
+	every   uint64
+	seen    uint64
+	records []EvictionRecord
+	next    int
+	full    bool
+}
+
+// WithEvictionAudit records one in every evictions in an audit trail keeping
+// the last size records, to explain why items disappear from the cache. The
+// trail is retrieved with EvictionAudit. If every is less than 2, all
+// evictions are recorded.
+func WithEvictionAudit(size, every int) Option {
+	return func(c *Cache) {
+		if size < 1 {
+			size = 1
+		}
+		if every < 1 {
+			every = 1
+		}
+		c.audit = &audit{every: uint64(every), records: make([]EvictionRecord, size)}
+	}
+}
+
+// EvictionAudit returns the recorded evictions from the oldest to the newest
+// one. It returns nil if the cache is not created with WithEvictionAudit.
+func (c *Cache) EvictionAudit() []EvictionRecord {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.audit == nil {
+		return nil
+	}
+	a := c.audit
+	if !a.full {
+		records := make([]EvictionRecord, a.next)
+		copy(records, a.records[:a.next])
+		return records
+	}
+	records := make([]EvictionRecord, 0, len(a.records))
+	records = append(records, a.records[a.next:]...)
+	return append(records, a.records[:a.next]...)
+}
+
+// record adds the eviction of the item to the trail if it is sampled.
+func (a *audit) record(item Item, ns string, reason EvictionReason) {
+	if a == nil {
+		return
+	}
+	a.seen++
+	if (a.seen-1)%a.every != 0 {
+		return
+	}
+	now := time.Now()
+	a.records[a.next] = EvictionRecord{
+		Key:       item.Key,
+		Namespace: ns,
+		Reason:    reason,
+		Time:      now,
+		Age:       time.Duration(now.UnixNano() - item.Created),
+		Idle:      time.Duration(now.UnixNano() - item.Accessed),
+		Hits:      item.Hits,
+	}
+	if a.next++; a.next == len(a.records) {
+		a.next = 0
+		a.full = true
+	}
+}
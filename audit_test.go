@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestWithEvictionAudit(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Option
+		capacity    int
+		addPairs    [][]any
+		resize      int
+		wantKeys    []any
+		wantReasons []EvictionReason
+	}{
+		{
+			name:        "records capacity evictions",
+			opts:        []Option{WithEvictionAudit(10, 1)},
+			capacity:    1,
+			addPairs:    [][]any{{k, v}, {k + k, v}, {k + k + k, v}},
+			wantKeys:    []any{k, k + k},
+			wantReasons: []EvictionReason{EvictCapacity, EvictCapacity},
+		},
+		{
+			name:        "keeps only the last records",
+			opts:        []Option{WithEvictionAudit(2, 1)},
+			capacity:    1,
+			addPairs:    [][]any{{"1", v}, {"2", v}, {"3", v}, {"4", v}},
+			wantKeys:    []any{"2", "3"},
+			wantReasons: []EvictionReason{EvictCapacity, EvictCapacity},
+		},
+		{
+			name:        "samples evictions",
+			opts:        []Option{WithEvictionAudit(10, 2)},
+			capacity:    1,
+			addPairs:    [][]any{{"1", v}, {"2", v}, {"3", v}, {"4", v}, {"5", v}},
+			wantKeys:    []any{"1", "3"},
+			wantReasons: []EvictionReason{EvictCapacity, EvictCapacity},
+		},
+		{
+			name:        "records quota and resize evictions",
+			opts:        []Option{WithEvictionAudit(10, 1), WithNamespace(PrefixNamespace(":")), WithNamespaceQuota("a", 1)},
+			capacity:    3,
+			addPairs:    [][]any{{"a:1", v}, {"b:1", v}, {"a:2", v}},
+			resize:      1,
+			wantKeys:    []any{"a:1", "b:1"},
+			wantReasons: []EvictionReason{EvictQuota, EvictResize},
+		},
+		{
+			name:     "records nothing without option",
+			capacity: 1,
+			addPairs: [][]any{{k, v}, {k + k, v}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(tt.capacity, tt.opts...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			addItems(t, c, tt.addPairs)
+			if tt.resize != 0 {
+				c.Resize(tt.resize)
+			}
+			got := c.EvictionAudit()
+			if len(got) != len(tt.wantKeys) {
+				t.Fatalf("unexpected records, got %+v, want keys %v", got, tt.wantKeys)
+			}
+			for i, r := range got {
+				if r.Key != tt.wantKeys[i] || r.Reason != tt.wantReasons[i] {
+					t.Errorf("unexpected record, got %v %v, want %v %v", r.Key, r.Reason, tt.wantKeys[i], tt.wantReasons[i])
+				}
+				if r.Age < 0 || r.Idle < 0 || r.Time.IsZero() {
+					t.Errorf("unexpected record times, got %+v", r)
+				}
+			}
+		})
+	}
+}
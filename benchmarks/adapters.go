@@ -0,0 +1,106 @@
+package benchmarks
+
+import (
+	"context"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/dgraph-io/ristretto/v2"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// golangLRU adapts the cache of hashicorp/golang-lru to Cacher.
+type golangLRU struct {
+	c *lru.Cache[string, []byte]
+}
+
+// GolangLRU returns a Factory creating the LRU caches of
+// hashicorp/golang-lru.
+func GolangLRU() Factory {
+	return func(capacity int) Cacher {
+		c, err := lru.New[string, []byte](capacity)
+		if err != nil {
+			panic(err)
+		}
+		return golangLRU{c: c}
+	}
+}
+
+// Set adds or overwrites the value of the key.
+func (a golangLRU) Set(key string, val []byte) {
+	a.c.Add(key, val)
+}
+
+// Get returns the value of the key and whether it is found.
+func (a golangLRU) Get(key string) ([]byte, bool) {
+	return a.c.Get(key)
+}
+
+// ristrettoCache adapts the cache of ristretto to Cacher.
+type ristrettoCache struct {
+	c *ristretto.Cache[string, []byte]
+}
+
+// Ristretto returns a Factory creating the caches of ristretto, where every
+// item costs 1, so the capacity limits the number of items. The number of
+// counters is 10 times the capacity, as the documentation of ristretto
+// recommends.
+func Ristretto() Factory {
+	return func(capacity int) Cacher {
+		c, err := ristretto.NewCache(&ristretto.Config[string, []byte]{
+			NumCounters: 10 * int64(capacity),
+			MaxCost:     int64(capacity),
+			BufferItems: 64,
+		})
+		if err != nil {
+			panic(err)
+		}
+		return ristrettoCache{c: c}
+	}
+}
+
+// Set adds or overwrites the value of the key. Ristretto applies sets
+// asynchronously and may drop them under contention, which the hit ratio
+// reflects.
+func (a ristrettoCache) Set(key string, val []byte) {
+	a.c.Set(key, val, 1)
+}
+
+// Get returns the value of the key and whether it is found.
+func (a ristrettoCache) Get(key string) ([]byte, bool) {
+	return a.c.Get(key)
+}
+
+// bigCache adapts the cache of bigcache to Cacher.
+type bigCache struct {
+	c *bigcache.BigCache
+}
+
+// BigCache returns a Factory creating the caches of bigcache. Bigcache limits
+// the memory of its shards rather than the number of items, and it evicts by
+// insertion time, so the capacity only sizes its shards initially and its
+// hit ratio is not directly comparable to the caches limited by count.
+func BigCache() Factory {
+	return func(capacity int) Cacher {
+		cfg := bigcache.DefaultConfig(time.Hour)
+		cfg.Shards = 64
+		cfg.MaxEntriesInWindow = capacity
+		cfg.Verbose = false
+		c, err := bigcache.New(context.Background(), cfg)
+		if err != nil {
+			panic(err)
+		}
+		return bigCache{c: c}
+	}
+}
+
+// Set adds or overwrites the value of the key.
+func (a bigCache) Set(key string, val []byte) {
+	a.c.Set(key, val)
+}
+
+// Get returns the value of the key and whether it is found.
+func (a bigCache) Get(key string) ([]byte, bool) {
+	val, err := a.c.Get(key)
+	return val, err == nil
+}
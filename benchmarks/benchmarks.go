@@ -0,0 +1,133 @@
+// Package benchmarks compares the throughput and the hit ratio of caches
+// under the same workloads. Caches are plugged in through the Cacher
+// interface, whose methods take string keys and []byte values. Adapters are
+// provided for package cache, with any options like its eviction policies,
+// and for hashicorp/golang-lru, ristretto and bigcache. The package is a
+// separate module, so package cache doesn't depend on the caches it is
+// compared with.
+package benchmarks
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gozeloglu/cache"
+)
+
+// Cacher is the part of a cache API exercised by the benchmarks.
+type Cacher interface {
+	// Set adds or overwrites the value of the key.
+	Set(key string, val []byte)
+
+	// Get returns the value of the key and whether it is found.
+	Get(key string) ([]byte, bool)
+}
+
+// Factory creates a Cacher holding up to capacity items.
+type Factory func(capacity int) Cacher
+
+// adapter adapts *cache.Cache to Cacher.
+type adapter struct {
+	c *cache.Cache
+}
+
+// New returns a Factory creating caches of package cache with the given
+// options.
+func New(opts ...cache.Option) Factory {
+	return func(capacity int) Cacher {
+		c, err := cache.New(capacity, opts...)
+		if err != nil {
+			panic(err)
+		}
+		return adapter{c: c}
+	}
+}
+
+// Set adds the value of the key, or replaces it if the key exists.
+func (a adapter) Set(key string, val []byte) {
+	if err := a.c.Add(key, val, 0); errors.Is(err, cache.ErrKeyExists) {
+		a.c.Replace(key, val)
+	}
+}
+
+// Get returns the value of the key and whether it is found.
+func (a adapter) Get(key string) ([]byte, bool) {
+	val, found := a.c.Get(key)
+	if !found {
+		return nil, false
+	}
+	return val.([]byte), true
+}
+
+// Workload is the access pattern of a benchmark.
+type Workload struct {
+	// Name is the name of the sub-benchmark.
+	Name string
+
+	// Keys is the number of distinct keys. The keys are picked with a Zipf
+	// distribution of parameter Skew, so a few keys are much more popular
+	// than the others. Skew must be bigger than 1.
+	Keys int
+	Skew float64
+
+	// WriteRatio is the ratio of Set calls, the others are Get calls. A Get
+	// which misses doesn't set the key.
+	WriteRatio float64
+}
+
+// Workloads are the default workloads of Run.
+var Workloads = []Workload{
+	{Name: "read heavy", Keys: 1 << 14, Skew: 1.1, WriteRatio: 0.1},
+	{Name: "write heavy", Keys: 1 << 14, Skew: 1.1, WriteRatio: 0.75},
+	{Name: "low skew", Keys: 1 << 14, Skew: 1.0001, WriteRatio: 0.1},
+}
+
+// Run runs the workloads against the caches created by factory with the given
+// capacity, as parallel sub-benchmarks. Besides the time per operation, the
+// hit ratio of the Get calls is reported as the "hits/op" metric. The cache is
+// filled with the keys of the workload before the timer starts.
+func Run(b *testing.B, factory Factory, capacity int, workloads ...Workload) {
+	if len(workloads) == 0 {
+		workloads = Workloads
+	}
+	for _, w := range workloads {
+		w := w
+		b.Run(w.Name, func(b *testing.B) {
+			c := factory(capacity)
+			val := make([]byte, 64)
+			keys := make([]string, w.Keys)
+			for i := range keys {
+				keys[i] = fmt.Sprintf("key-%d", i)
+				c.Set(keys[i], val)
+			}
+
+			var seed, gets, hits int64
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				r := rand.New(rand.NewSource(atomic.AddInt64(&seed, 1)))
+				zipf := rand.NewZipf(r, w.Skew, 1, uint64(w.Keys-1))
+				var g, h int64
+				for pb.Next() {
+					key := keys[zipf.Uint64()]
+					if r.Float64() < w.WriteRatio {
+						c.Set(key, val)
+						continue
+					}
+					g++
+					if _, found := c.Get(key); found {
+						h++
+					}
+				}
+				atomic.AddInt64(&gets, g)
+				atomic.AddInt64(&hits, h)
+			})
+			if gets > 0 {
+				b.ReportMetric(float64(hits)/float64(gets), "hits/op")
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package benchmarks
+
+import (
+	"testing"
+
+	"github.com/gozeloglu/cache"
+)
+
+// benchCapacity is the capacity of the benchmarked caches, a sixteenth of the
+// keys of the default workloads.
+const benchCapacity = 1 << 10
+
+func TestAdapter(t *testing.T) {
+	// Ristretto is left out, since it applies sets asynchronously.
+	factories := map[string]Factory{
+		"cache":      New(),
+		"golang-lru": GolangLRU(),
+		"bigcache":   BigCache(),
+	}
+	for name, factory := range factories {
+		t.Run(name, func(t *testing.T) {
+			c := factory(2)
+			if _, found := c.Get("a"); found {
+				t.Errorf("expected key %v not to be found", "a")
+			}
+			c.Set("a", []byte("1"))
+			c.Set("a", []byte("2"))
+			if got, found := c.Get("a"); !found || string(got) != "2" {
+				t.Errorf("unexpected value, got %q, want %q", got, "2")
+			}
+		})
+	}
+}
+
+func BenchmarkLRU(b *testing.B) {
+	Run(b, New(), benchCapacity)
+}
+
+func BenchmarkFIFO(b *testing.B) {
+	Run(b, New(cache.WithEvictionPolicy(cache.PolicyFIFO)), benchCapacity)
+}
+
+func BenchmarkSampled(b *testing.B) {
+	Run(b, New(cache.WithEvictionPolicy(cache.PolicySampled)), benchCapacity)
+}
+
+func BenchmarkSLRU(b *testing.B) {
+	Run(b, New(cache.WithEvictionPolicy(cache.PolicySLRU)), benchCapacity)
+}
+
+func BenchmarkGolangLRU(b *testing.B) {
+	Run(b, GolangLRU(), benchCapacity)
+}
+
+func BenchmarkRistretto(b *testing.B) {
+	Run(b, Ristretto(), benchCapacity)
+}
+
+func BenchmarkBigCache(b *testing.B) {
+	Run(b, BigCache(), benchCapacity)
+}
@@ -0,0 +1,18 @@
+module github.com/gozeloglu/cache/benchmarks
+
+go 1.24.0
+
+require (
+	github.com/allegro/bigcache/v3 v3.1.0
+	github.com/dgraph-io/ristretto/v2 v2.4.2
+	github.com/gozeloglu/cache v0.0.0
+	github.com/hashicorp/golang-lru/v2 v2.0.7
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	golang.org/x/sys v0.36.0 // indirect
+)
+
+replace github.com/gozeloglu/cache => ../
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BlobStore stores snapshots as named blobs, e.g. in a directory or an
+// object storage bucket like S3 or GCS.
+type BlobStore interface {
+	// Put stores the content of r with the given name, replacing the blob
+	// with the same name. A reader of the name must never observe a
+	// partially written blob.
+	Put(ctx context.Context, name string, r io.Reader) error
+
+	// Get returns the content of the blob with the given name.
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+
+	// List returns the names of the blobs with the given prefix in
+	// lexicographical order.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// FileStore is a BlobStore keeping blobs as files in a directory. Names must
+// be plain file names, so a blob can't be written or read outside the
+// directory.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a FileStore keeping blobs in the given directory. The
+// directory must exist.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// Put writes the blob to a temporary file and renames it, so a crash while
+// writing never leaves a partially written blob.
+func (s *FileStore) Put(ctx context.Context, name string, r io.Reader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := checkBlobName(name); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.dir, name), func(w io.Writer) error {
+		_, err := io.Copy(w, r)
+		return err
+	}, nil)
+}
+
+// Get opens the file of the blob.
+func (s *FileStore) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := checkBlobName(name); err != nil {
+		return nil, err
+	}
+	return os.Open(filepath.Join(s.dir, name))
+}
+
+// checkBlobName returns error if the name is not a plain file name, e.g. if it
+// contains a path separator or is "..".
+func checkBlobName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || filepath.Base(name) != name {
+		return fmt.Errorf("invalid blob name %q", name)
+	}
+	return nil
+}
+
+// List returns the names of the files in the directory with the given prefix.
+// Temporary files of unfinished writes are not listed.
+func (s *FileStore) List(ctx context.Context, prefix string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || strings.Contains(name, ".tmp-") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// SaveBlob writes a snapshot of the cache to the store with the given name,
+// see Save.
+func (c *Cache) SaveBlob(ctx context.Context, store BlobStore, name string) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(c.Save(pw))
+	}()
+	err := store.Put(ctx, name, pr)
+	pr.CloseWithError(err)
+	return err
+}
+
+// LoadBlob adds the items of the snapshot with the given name in the store to
+// cache, see Load.
+func (c *Cache) LoadBlob(ctx context.Context, store BlobStore, name string) (RestoreReport, error) {
+	rc, err := store.Get(ctx, name)
+	if err != nil {
+		return RestoreReport{}, err
+	}
+	defer rc.Close()
+	return c.Load(rc)
+}
+
+// LoadLatestBlob loads the snapshot whose name is the last one in
+// lexicographical order among the names with the given prefix, see LoadBlob.
+// Using sortable timestamps in names, e.g. "cache-20060102T150405", makes it
+// load the most recent snapshot. It returns error if there is no snapshot
+// with the prefix.
+func (c *Cache) LoadLatestBlob(ctx context.Context, store BlobStore, prefix string) (RestoreReport, error) {
+	names, err := store.List(ctx, prefix)
+	if err != nil {
+		return RestoreReport{}, err
+	}
+	if len(names) == 0 {
+		return RestoreReport{}, errNoSnapshot
+	}
+	return c.LoadBlob(ctx, store, names[len(names)-1])
+}
@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestFileStore_List(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"cache-2", "cache-1", "other", "cache-3.tmp-123"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "cache-dir"), 0o700); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	tests := []struct {
+		name   string
+		prefix string
+		want   []string
+	}{
+		{
+			name:   "lists sorted names with prefix",
+			prefix: "cache-",
+			want:   []string{"cache-1", "cache-2"},
+		},
+		{
+			name:   "lists all files with empty prefix",
+			prefix: "",
+			want:   []string{"cache-1", "cache-2", "other"},
+		},
+		{
+			name:   "returns nothing for unknown prefix",
+			prefix: "unknown",
+			want:   nil,
+		},
+	}
+	store := NewFileStore(dir)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.List(context.Background(), tt.prefix)
+			if err != nil {
+				t.Fatalf("unexpected error, got %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("store.List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileStore_InvalidName(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "blobs")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatalf(err.Error())
+	}
+	store := NewFileStore(dir)
+	ctx := context.Background()
+	for _, name := range []string{"", ".", "..", "../x", "a/b", `a\b`} {
+		t.Run(name, func(t *testing.T) {
+			if err := store.Put(ctx, name, bytes.NewReader(nil)); err == nil {
+				t.Errorf("expected Put to reject name %q", name)
+			}
+			if _, err := store.Get(ctx, name); err == nil {
+				t.Errorf("expected Get to reject name %q", name)
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(parent, "x")); !os.IsNotExist(err) {
+		t.Errorf("expected no blob outside the directory, got %v", err)
+	}
+}
+
+func TestCache_SaveBlob(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileStore(t.TempDir())
+	c := createCache(t, 3)
+	addItems(t, c, [][]any{{k, v}, {k + k, v + v}})
+	if err := c.SaveBlob(ctx, store, "cache-1"); err != nil {
+		t.Fatalf("unexpected error, got %v", err)
+	}
+
+	rc, err := store.Get(ctx, "cache-1")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer rc.Close()
+	var got, want bytes.Buffer
+	if _, err := got.ReadFrom(rc); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := c.Save(&want); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !bytes.Equal(got.Bytes(), want.Bytes()) {
+		t.Errorf("expected blob to hold the snapshot")
+	}
+}
+
+func TestCache_LoadLatestBlob(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		saves    [][]any
+		wantKeys []any
+		wantErr  error
+	}{
+		{
+			name:     "loads the last snapshot in order",
+			saves:    [][]any{{k, v}, {k + k, v + v}},
+			wantKeys: []any{k + k},
+		},
+		{
+			name:    "returns error without snapshots",
+			saves:   [][]any{},
+			wantErr: errNoSnapshot,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewFileStore(t.TempDir())
+			for i, pair := range tt.saves {
+				c := createCache(t, 3)
+				addItems(t, c, [][]any{pair})
+				if err := c.SaveBlob(ctx, store, "cache-"+strconv.Itoa(i)); err != nil {
+					t.Fatalf(err.Error())
+				}
+			}
+
+			restored := createCache(t, 3)
+			_, err := restored.LoadLatestBlob(ctx, store, "cache-")
+			if err != tt.wantErr {
+				t.Fatalf("unexpected error, got %v, want %v", err, tt.wantErr)
+			}
+			cmpCacheListOrder(t, restored, tt.wantKeys)
+		})
+	}
+}
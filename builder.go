@@ -0,0 +1,396 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CacheBuilder configures a cache step by step. Unlike passing options to
+// New, which ignore invalid values, Build validates the whole configuration
+// and reports all problems at once. Methods can be chained, e.g.
+//
+//	c, err := cache.Builder().Capacity(100).LockMode(cache.LockFair).Build()
+type CacheBuilder struct {
+	cap        int
+	lockMode   LockMode
+	nsFn       func(key interface{}) string
+	quotas     map[string]int
+	quotaOrder []string
+	ttlRules   []TTLRule
+	codecs     []Codec
+	valCodecs  []Codec
+	hotKeys    bool
+	hotLimit   int
+	hotWindow  time.Duration
+	hotFn      func(key interface{})
+	noPromote  bool
+	policy     EvictionPolicy
+	admit      func(key, val interface{}, exp time.Duration) bool
+	validator  func(key, val interface{}) error
+	repair     RepairFunc
+	defaultTTL time.Duration
+	janitor    time.Duration
+	janitorMin time.Duration
+	janitorMax time.Duration
+	onEvict    func(key, val interface{})
+	bytes      bool
+	maxBytes   int64
+	sizeFn     func(key, val interface{}) int64
+	cost       bool
+	maxCost    int64
+	idle       time.Duration
+	refresh    func(key interface{}) (interface{}, error)
+	panicFn    func(*PanicError)
+	loads      bool
+	maxLoads   int
+	nsStats    []string
+	restore    bool
+	store      BlobStore
+	prefix     string
+}
+
+// Builder returns a CacheBuilder with no configuration. At least the capacity
+// must be set before calling Build.
+func Builder() *CacheBuilder {
+	return &CacheBuilder{quotas: make(map[string]int)}
+}
+
+// Capacity sets the capacity of the cache, see New.
+func (b *CacheBuilder) Capacity(cap int) *CacheBuilder {
+	b.cap = cap
+	return b
+}
+
+// LockMode sets the locking strategy of the cache, see WithLockMode.
+func (b *CacheBuilder) LockMode(mode LockMode) *CacheBuilder {
+	b.lockMode = mode
+	return b
+}
+
+// Namespace sets the function that derives the namespace of a key, see
+// WithNamespace.
+func (b *CacheBuilder) Namespace(fn func(key interface{}) string) *CacheBuilder {
+	b.nsFn = fn
+	return b
+}
+
+// NamespaceQuota limits the number of items in the namespace, see
+// WithNamespaceQuota. It requires Namespace to be set.
+func (b *CacheBuilder) NamespaceQuota(ns string, max int) *CacheBuilder {
+	if _, ok := b.quotas[ns]; !ok {
+		b.quotaOrder = append(b.quotaOrder, ns)
+	}
+	b.quotas[ns] = max
+	return b
+}
+
+// TTLRules sets the default expiration durations by key pattern, see
+// WithTTLRules.
+func (b *CacheBuilder) TTLRules(rules []TTLRule) *CacheBuilder {
+	b.ttlRules = rules
+	return b
+}
+
+// SnapshotCodecs sets the codecs of the snapshots, see WithSnapshotCodecs.
+func (b *CacheBuilder) SnapshotCodecs(codecs ...Codec) *CacheBuilder {
+	b.codecs = codecs
+	return b
+}
+
+// ValueCodecs sets the codecs of the values, see WithValueCodecs.
+func (b *CacheBuilder) ValueCodecs(codecs ...Codec) *CacheBuilder {
+	b.valCodecs = codecs
+	return b
+}
+
+// HotKeys enables the detection of hot keys, see WithHotKeys.
+func (b *CacheBuilder) HotKeys(threshold int, window time.Duration, fn func(key interface{})) *CacheBuilder {
+	b.hotKeys = true
+	b.hotLimit = threshold
+	b.hotWindow = window
+	b.hotFn = fn
+	return b
+}
+
+// GetDoesNotPromote makes Get keep the access order of the cache, see
+// WithGetDoesNotPromote.
+func (b *CacheBuilder) GetDoesNotPromote() *CacheBuilder {
+	b.noPromote = true
+	return b
+}
+
+// EvictionPolicy sets the eviction policy, see WithEvictionPolicy.
+func (b *CacheBuilder) EvictionPolicy(policy EvictionPolicy) *CacheBuilder {
+	b.policy = policy
+	return b
+}
+
+// AdmitFunc sets the admission function, see WithAdmitFunc.
+func (b *CacheBuilder) AdmitFunc(fn func(key, val interface{}, exp time.Duration) bool) *CacheBuilder {
+	b.admit = fn
+	return b
+}
+
+// Validator sets the function that checks the values, see WithValidator.
+func (b *CacheBuilder) Validator(fn func(key, val interface{}) error) *CacheBuilder {
+	b.validator = fn
+	return b
+}
+
+// ReadRepair sets the function called for expired items, see WithReadRepair.
+func (b *CacheBuilder) ReadRepair(fn RepairFunc) *CacheBuilder {
+	b.repair = fn
+	return b
+}
+
+// DefaultTTL sets the expiration duration of the items matching no TTL rule,
+// see WithDefaultTTL.
+func (b *CacheBuilder) DefaultTTL(ttl time.Duration) *CacheBuilder {
+	b.defaultTTL = ttl
+	return b
+}
+
+// Janitor starts a janitor removing the expired items every interval, see
+// WithJanitor. The built cache must be closed.
+func (b *CacheBuilder) Janitor(interval time.Duration) *CacheBuilder {
+	b.janitor = interval
+	return b
+}
+
+// AdaptiveJanitor starts a janitor whose interval adapts between min and max,
+// see WithAdaptiveJanitor. It conflicts with Janitor. The built cache must be
+// closed.
+func (b *CacheBuilder) AdaptiveJanitor(min, max time.Duration) *CacheBuilder {
+	b.janitorMin = min
+	b.janitorMax = max
+	return b
+}
+
+// OnEvict sets the callback called for the removed items, see WithOnEvict.
+func (b *CacheBuilder) OnEvict(fn func(key, val interface{})) *CacheBuilder {
+	b.onEvict = fn
+	return b
+}
+
+// MaxBytes limits the total size of the items in bytes, see WithMaxBytes. It
+// conflicts with MaxCost.
+func (b *CacheBuilder) MaxBytes(max int64, sizeFn func(key, val interface{}) int64) *CacheBuilder {
+	b.bytes = true
+	b.maxBytes = max
+	b.sizeFn = sizeFn
+	return b
+}
+
+// MaxCost limits the total cost of the items, see WithMaxCost. It conflicts
+// with MaxBytes.
+func (b *CacheBuilder) MaxCost(max int64) *CacheBuilder {
+	b.cost = true
+	b.maxCost = max
+	return b
+}
+
+// ExpireAfterAccess sets the idle timeout of the items, see
+// WithExpireAfterAccess.
+func (b *CacheBuilder) ExpireAfterAccess(d time.Duration) *CacheBuilder {
+	b.idle = d
+	return b
+}
+
+// Refresh sets the function reloading the stale items, see WithRefresh.
+func (b *CacheBuilder) Refresh(fn func(key interface{}) (interface{}, error)) *CacheBuilder {
+	b.refresh = fn
+	return b
+}
+
+// PanicHandler sets the function receiving the recovered panics, see
+// WithPanicHandler.
+func (b *CacheBuilder) PanicHandler(fn func(*PanicError)) *CacheBuilder {
+	b.panicFn = fn
+	return b
+}
+
+// MaxConcurrentLoads limits the number of loaders running at once, see
+// WithMaxConcurrentLoads.
+func (b *CacheBuilder) MaxConcurrentLoads(n int) *CacheBuilder {
+	b.loads = true
+	b.maxLoads = n
+	return b
+}
+
+// NamespaceStats keeps statistics for the namespaces, see WithNamespaceStats.
+// It requires Namespace to be set.
+func (b *CacheBuilder) NamespaceStats(ns ...string) *CacheBuilder {
+	b.nsStats = append(b.nsStats, ns...)
+	return b
+}
+
+// Restore makes Build load the latest snapshot with the given prefix from the
+// store, see LoadLatestBlob. Finding no snapshot is not an error, so the first
+// start of a service builds an empty cache.
+func (b *CacheBuilder) Restore(store BlobStore, prefix string) *CacheBuilder {
+	b.restore = true
+	b.store = store
+	b.prefix = prefix
+	return b
+}
+
+// Build validates the configuration and creates the cache. If the
+// configuration is invalid, it returns a *ConfigError holding all problems.
+// If the snapshot set with Restore can't be loaded, it returns its error.
+func (b *CacheBuilder) Build() (*Cache, error) {
+	if errs := b.validate(); len(errs) > 0 {
+		return nil, &ConfigError{Errs: errs}
+	}
+	opts := []Option{WithLockMode(b.lockMode)}
+	if b.nsFn != nil {
+		opts = append(opts, WithNamespace(b.nsFn))
+	}
+	for _, ns := range b.quotaOrder {
+		opts = append(opts, WithNamespaceQuota(ns, b.quotas[ns]))
+	}
+	if len(b.ttlRules) > 0 {
+		opts = append(opts, WithTTLRules(b.ttlRules))
+	}
+	if len(b.codecs) > 0 {
+		opts = append(opts, WithSnapshotCodecs(b.codecs...))
+	}
+	if len(b.valCodecs) > 0 {
+		opts = append(opts, WithValueCodecs(b.valCodecs...))
+	}
+	if b.hotKeys {
+		opts = append(opts, WithHotKeys(b.hotLimit, b.hotWindow, b.hotFn))
+	}
+	if b.noPromote {
+		opts = append(opts, WithGetDoesNotPromote())
+	}
+	if b.policy != PolicyLRU {
+		opts = append(opts, WithEvictionPolicy(b.policy))
+	}
+	if b.admit != nil {
+		opts = append(opts, WithAdmitFunc(b.admit))
+	}
+	if b.validator != nil {
+		opts = append(opts, WithValidator(b.validator))
+	}
+	if b.repair != nil {
+		opts = append(opts, WithReadRepair(b.repair))
+	}
+	if b.defaultTTL > 0 {
+		opts = append(opts, WithDefaultTTL(b.defaultTTL))
+	}
+	if b.onEvict != nil {
+		opts = append(opts, WithOnEvict(b.onEvict))
+	}
+	if b.bytes {
+		opts = append(opts, WithMaxBytes(b.maxBytes, b.sizeFn))
+	}
+	if b.cost {
+		opts = append(opts, WithMaxCost(b.maxCost))
+	}
+	if b.idle > 0 {
+		opts = append(opts, WithExpireAfterAccess(b.idle))
+	}
+	if b.refresh != nil {
+		opts = append(opts, WithRefresh(b.refresh))
+	}
+	if b.panicFn != nil {
+		opts = append(opts, WithPanicHandler(b.panicFn))
+	}
+	if b.loads {
+		opts = append(opts, WithMaxConcurrentLoads(b.maxLoads))
+	}
+	if len(b.nsStats) > 0 {
+		opts = append(opts, WithNamespaceStats(b.nsStats...))
+	}
+	if b.janitor > 0 {
+		opts = append(opts, WithJanitor(b.janitor))
+	}
+	if b.janitorMin > 0 {
+		opts = append(opts, WithAdaptiveJanitor(b.janitorMin, b.janitorMax))
+	}
+	c, err := New(b.cap, opts...)
+	if err != nil || !b.restore {
+		return c, err
+	}
+	if _, err := c.LoadLatestBlob(context.Background(), b.store, b.prefix); err != nil && !errors.Is(err, errNoSnapshot) {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// validate returns all problems of the configuration. The settings that
+// Reconfigure can change are checked like Reconfigure does.
+func (b *CacheBuilder) validate() []error {
+	cfg := Config{
+		Capacity:        b.cap,
+		DefaultTTL:      b.defaultTTL,
+		TTLRules:        b.ttlRules,
+		JanitorInterval: b.janitor,
+	}
+	errs := cfg.problems()
+	if b.lockMode < LockExclusive || b.lockMode > LockFair {
+		errs = append(errs, fmt.Errorf("unknown lock mode %d", b.lockMode))
+	}
+	if b.policy < PolicyLRU || b.policy > PolicySLRU {
+		errs = append(errs, fmt.Errorf("unknown eviction policy %d", b.policy))
+	}
+	if len(b.quotas) > 0 && b.nsFn == nil {
+		errs = append(errs, errQuotaNoNamespace)
+	}
+	for _, ns := range b.quotaOrder {
+		if max := b.quotas[ns]; max < 1 || (b.cap > 0 && max > b.cap) {
+			errs = append(errs, fmt.Errorf("quota %d of namespace %q should be between 1 and the capacity", max, ns))
+		}
+	}
+	if len(b.nsStats) > 0 && b.nsFn == nil {
+		errs = append(errs, errors.New("namespace stats require a namespace function"))
+	}
+	for i, codec := range b.codecs {
+		if codec == nil {
+			errs = append(errs, fmt.Errorf("snapshot codec %d is nil", i))
+		}
+	}
+	for i, codec := range b.valCodecs {
+		if codec == nil {
+			errs = append(errs, fmt.Errorf("value codec %d is nil", i))
+		}
+	}
+	if b.hotKeys {
+		if b.hotLimit < 1 {
+			errs = append(errs, fmt.Errorf("hot key threshold %d should be more than zero", b.hotLimit))
+		}
+		if b.hotWindow <= 0 {
+			errs = append(errs, fmt.Errorf("hot key window %v should be more than zero", b.hotWindow))
+		}
+	}
+	if b.janitorMin != 0 || b.janitorMax != 0 {
+		if b.janitorMin <= 0 || b.janitorMax < b.janitorMin {
+			errs = append(errs, fmt.Errorf("adaptive janitor interval should be between a positive min %v and a max %v not less than it", b.janitorMin, b.janitorMax))
+		}
+		if b.janitor != 0 {
+			errs = append(errs, errors.New("janitor and adaptive janitor can't both be set"))
+		}
+	}
+	if b.bytes && b.maxBytes <= 0 {
+		errs = append(errs, fmt.Errorf("max bytes %d should be more than zero", b.maxBytes))
+	}
+	if b.cost && b.maxCost <= 0 {
+		errs = append(errs, fmt.Errorf("max cost %d should be more than zero", b.maxCost))
+	}
+	if b.bytes && b.cost {
+		errs = append(errs, errors.New("max bytes and max cost can't both be set"))
+	}
+	if b.idle < 0 {
+		errs = append(errs, fmt.Errorf("idle timeout %v should not be negative", b.idle))
+	}
+	if b.loads && b.maxLoads < 1 {
+		errs = append(errs, fmt.Errorf("max concurrent loads %d should be more than zero", b.maxLoads))
+	}
+	if b.restore && b.store == nil {
+		errs = append(errs, errors.New("restore requires a blob store"))
+	}
+	return errs
+}
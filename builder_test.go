@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCacheBuilder_Build(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  *CacheBuilder
+		wantErrs int
+	}{
+		{
+			name:     "builds valid configuration",
+			builder:  Builder().Capacity(10).LockMode(LockFair).Namespace(PrefixNamespace(":")).NamespaceQuota("a", 5).HotKeys(10, time.Minute, nil),
+			wantErrs: 0,
+		},
+		{
+			name:     "reports missing capacity",
+			builder:  Builder(),
+			wantErrs: 1,
+		},
+		{
+			name:     "reports all problems at once",
+			builder:  Builder().Capacity(-1).LockMode(LockMode(42)).NamespaceQuota("a", 0).TTLRules([]TTLRule{{Pattern: "[", TTL: -time.Second}}).HotKeys(0, 0, nil),
+			wantErrs: 8,
+		},
+		{
+			name:     "reports negative ttl rule like Reconfigure",
+			builder:  Builder().Capacity(2).TTLRules([]TTLRule{{Pattern: "user:*", TTL: -time.Second}}),
+			wantErrs: 1,
+		},
+		{
+			name:     "reports nil codecs and unknown policy",
+			builder:  Builder().Capacity(2).SnapshotCodecs(nil).ValueCodecs(nil).EvictionPolicy(EvictionPolicy(42)),
+			wantErrs: 3,
+		},
+		{
+			name:     "reports conflicting limits",
+			builder:  Builder().Capacity(2).MaxBytes(0, nil).MaxCost(10).Janitor(time.Minute).AdaptiveJanitor(time.Minute, time.Second),
+			wantErrs: 4,
+		},
+		{
+			name:     "reports invalid loaders and persistence",
+			builder:  Builder().Capacity(2).MaxConcurrentLoads(0).ExpireAfterAccess(-time.Second).NamespaceStats("a").Restore(nil, "cache-"),
+			wantErrs: 4,
+		},
+		{
+			name:     "reports quota over capacity",
+			builder:  Builder().Capacity(2).Namespace(PrefixNamespace(":")).NamespaceQuota("a", 3),
+			wantErrs: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := tt.builder.Build()
+			if tt.wantErrs == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error, got %v", err)
+				}
+				if c == nil {
+					t.Fatalf("expected cache to be created")
+				}
+				return
+			}
+			var cfgErr *ConfigError
+			if !errors.As(err, &cfgErr) {
+				t.Fatalf("expected *ConfigError, got %v", err)
+			}
+			if len(cfgErr.Errs) != tt.wantErrs {
+				t.Errorf("unexpected number of errors, got %v, want %v", cfgErr.Errs, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestCacheBuilder_Options(t *testing.T) {
+	c, err := Builder().Capacity(3).Namespace(PrefixNamespace(":")).NamespaceQuota("a", 1).GetDoesNotPromote().Build()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	want, err := New(3, WithNamespace(PrefixNamespace(":")), WithNamespaceQuota("a", 1), WithGetDoesNotPromote())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !reflect.DeepEqual(c.quotas, want.quotas) || c.noPromote != want.noPromote || c.nsFn == nil {
+		t.Errorf("expected builder to apply the options")
+	}
+}
+
+func TestCacheBuilder_Restore(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileStore(t.TempDir())
+	c, err := Builder().Capacity(3).Restore(store, "cache-").Build()
+	if err != nil {
+		t.Fatalf("expected empty cache without snapshot, got %v", err)
+	}
+	if err := c.Add(k, v, 0); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := c.SaveBlob(ctx, store, "cache-1"); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	var evicted []any
+	restored, err := Builder().Capacity(3).MaxCost(5).OnEvict(func(key, _ any) {
+		evicted = append(evicted, key)
+	}).Janitor(time.Minute).Restore(store, "cache-").Build()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer restored.Close()
+	if val, ok := restored.Get(k); !ok || val != v {
+		t.Errorf("expected restored item, got %v, %v", val, ok)
+	}
+	if restored.maxCost != 5 || !restored.weighted || restored.janitor == nil {
+		t.Errorf("expected builder to apply the options")
+	}
+	restored.Remove(k)
+	if len(evicted) != 1 {
+		t.Errorf("expected eviction callback, got %v", evicted)
+	}
+}
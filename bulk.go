@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// ToMap returns the key-value pairs in cache as a map. Expired and
+// invalidated items are not included. The map is a snapshot, later changes
+// to the cache do not affect it. It does not change frequency of the item
+// access.
+func (c *Cache) ToMap() map[interface{}]interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	m := make(map[interface{}]interface{}, c.len)
+	for e := c.lst.Front(); e != nil; e = e.Next() {
+		if item := mustItem(e); !c.stale(item) && !item.Expired() {
+			if val, ok := c.decoded(item.Val, true); ok {
+				m[item.Key] = val
+			}
+		}
+	}
+	return m
+}
+
+// FromMap adds the key-value pairs of m to cache with the given expiration
+// duration, like calling Add for each pair, so the default expiration
+// durations apply if it is 0. It never evicts items, it stops adding when the
+// cache is full, and skips the pairs which don't fit in the quota of their
+// namespace or in the size or cost limit. Since the map is unordered, which
+// pairs are added is unspecified if m does not fit in cache. Pairs whose key
+// already exists or that are rejected by the validator or the admission
+// function are skipped. The pairs are mirrored to the shadow set with
+// WithShadow like Add calls with NoEvictOthers. It returns the number of added
+// pairs.
+func (c *Cache) FromMap(m map[interface{}]interface{}, exp time.Duration) int {
+	n, _ := c.fromMap(context.Background(), m, exp, 0, callConfig{})
+	return n
+}
+
+// Warm pre-populates the cache with the key-value pairs of m like FromMap,
+// tagging the items with the "warmup" source, see Source. It adds at most
+// rate pairs per second, so that pre-populating hundreds of thousands of
+// items at startup doesn't monopolize the CPU or the lock of the cache. A
+// rate of 0 means no limit. If ctx is done before all pairs are handled, it
+// stops and returns the error of ctx. It returns the number of added pairs.
+func (c *Cache) Warm(ctx context.Context, m map[interface{}]interface{}, exp time.Duration, rate int) (n int, err error) {
+	defer recoverCorrupt(&err)
+	return c.fromMap(ctx, m, exp, rate, callConfig{source: "warmup"})
+}
+
+// fromMap adds the pairs of m to cache until it is full, at most rate pairs
+// per second if rate is positive, and stops when ctx is done.
+func (c *Cache) fromMap(ctx context.Context, m map[interface{}]interface{}, exp time.Duration, rate int, cfg callConfig) (int, error) {
+	var n, i int
+	start := time.Now()
+	for key, val := range m {
+		due := start
+		if rate > 0 {
+			due = start.Add(time.Duration(i) * time.Second / time.Duration(rate))
+		}
+		if err := sleepUntil(ctx, due); err != nil {
+			return n, err
+		}
+		i++
+
+		exp := c.ttlOf(key, exp)
+		if c.accept(key, val, exp) != nil {
+			continue
+		}
+		encoded, err := c.encodeVal(val)
+		if err != nil {
+			continue
+		}
+
+		added, full := c.addIfRoom(key, encoded, exp, cfg)
+		if full {
+			break
+		}
+		if c.shadow != nil {
+			c.shadow.Add(key, val, exp, NoEvictOthers(), Source(cfg.source))
+		}
+		if added {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// addIfRoom adds the item for fromMap unless it requires evicting others. It
+// reports whether the item is added and whether the cache is full.
+func (c *Cache) addIfRoom(key, val interface{}, exp time.Duration, cfg callConfig) (added, full bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.hasRoom() {
+		return false, true
+	}
+	if !c.hasRoomFor(key) || !c.hasCostFor(key, val, cfg) {
+		return false, false
+	}
+	return c.add(key, val, exp, cfg) == nil, false
+}
+
+// sleepUntil waits until t or until ctx is done, in which case it returns the
+// error of ctx.
+func sleepUntil(ctx context.Context, t time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d := time.Until(t)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCache_ToMap(t *testing.T) {
+	tests := []struct {
+		name     string
+		addPairs [][]any
+		want     map[any]any
+	}{
+		{
+			name:     "returns empty map for empty cache",
+			addPairs: [][]any{},
+			want:     map[any]any{},
+		},
+		{
+			name:     "returns all unexpired pairs",
+			addPairs: [][]any{{k, v, time.Duration(0)}, {k + k, v + v, time.Hour}, {k + k + k, v + v + v, -1 * time.Hour}},
+			want:     map[any]any{k: v, k + k: v + v},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := createCache(t, 3)
+			addItemsWithExp(t, c, tt.addPairs)
+			got := c.ToMap()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cache.ToMap() = %v, want %v", got, tt.want)
+			}
+			got[k] = "changed"
+			if val, _ := c.Peek(k); val == "changed" {
+				t.Errorf("expected map to be a snapshot")
+			}
+		})
+	}
+}
+
+func TestCache_FromMap(t *testing.T) {
+	tests := []struct {
+		name       string
+		capacity   int
+		opts       []Option
+		addPairs   [][]any
+		m          map[any]any
+		want       int
+		wantLength int
+		wantVals   map[any]any
+	}{
+		{
+			name:       "adds all pairs when they fit in cache",
+			capacity:   3,
+			addPairs:   [][]any{},
+			m:          map[any]any{k: v, k + k: v + v},
+			want:       2,
+			wantLength: 2,
+			wantVals:   map[any]any{k: v, k + k: v + v},
+		},
+		{
+			name:       "skips existing keys",
+			capacity:   3,
+			addPairs:   [][]any{{k, v + v + v}},
+			m:          map[any]any{k: v, k + k: v + v},
+			want:       1,
+			wantLength: 2,
+			wantVals:   map[any]any{k: v + v + v, k + k: v + v},
+		},
+		{
+			name:       "stops adding without evicting when cache is full",
+			capacity:   2,
+			addPairs:   [][]any{{k, v}},
+			m:          map[any]any{k + k: v + v, k + k + k: v + v + v},
+			want:       1,
+			wantLength: 2,
+			wantVals:   map[any]any{k: v},
+		},
+		{
+			name:       "skips pairs over namespace quota without evicting",
+			capacity:   3,
+			opts:       []Option{WithNamespace(PrefixNamespace(":")), WithNamespaceQuota("a", 1)},
+			addPairs:   [][]any{{"a:1", v}},
+			m:          map[any]any{"a:2": v, "b:1": v},
+			want:       1,
+			wantLength: 2,
+			wantVals:   map[any]any{"a:1": v, "b:1": v},
+		},
+		{
+			name:       "skips pairs over size limit without evicting",
+			capacity:   3,
+			opts:       []Option{WithMaxBytes(4, nil)},
+			addPairs:   [][]any{{k, []byte("abc")}},
+			m:          map[any]any{k + k: []byte("abc"), k + k + k: []byte("a")},
+			want:       1,
+			wantLength: 2,
+			wantVals:   map[any]any{k: []byte("abc"), k + k + k: []byte("a")},
+		},
+	}
+	for _, tt := range tests {
+		c, err := New(tt.capacity, tt.opts...)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		addItems(t, c, tt.addPairs)
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.FromMap(tt.m, time.Hour); got != tt.want {
+				t.Errorf("cache.FromMap() = %v, want %v", got, tt.want)
+			}
+			if c.Len() != tt.wantLength {
+				t.Errorf("unexpected length, got %v, want %v", c.Len(), tt.wantLength)
+			}
+			for key, want := range tt.wantVals {
+				if got, _ := c.Peek(key); !reflect.DeepEqual(got, want) {
+					t.Errorf("unexpected value of %v, got %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCache_FromMapShadow(t *testing.T) {
+	shadow := createCache(t, 3)
+	c, err := New(3, WithShadow(shadow))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	c.FromMap(map[any]any{k: v, k + k: v + v}, time.Hour)
+	if shadow.Len() != 2 {
+		t.Errorf("expected pairs to be mirrored to shadow, got length %v", shadow.Len())
+	}
+	for _, key := range []any{k, k + k} {
+		if _, found := shadow.Peek(key); !found {
+			t.Errorf("expected key %v to be mirrored to shadow", key)
+		}
+	}
+}
+
+func TestCache_Warm(t *testing.T) {
+	m := make(map[any]any)
+	for i := 0; i < 10; i++ {
+		m[i] = v
+	}
+	tests := []struct {
+		name        string
+		rate        int
+		timeout     time.Duration
+		wantErr     error
+		wantMin     int
+		wantMax     int
+		wantMinTime time.Duration
+	}{
+		{
+			name:    "adds all pairs without rate limit",
+			timeout: time.Second,
+			wantMin: 10,
+			wantMax: 10,
+		},
+		{
+			name:        "limits the rate",
+			rate:        100,
+			timeout:     time.Second,
+			wantMin:     10,
+			wantMax:     10,
+			wantMinTime: 90 * time.Millisecond,
+		},
+		{
+			name:    "stops when context is done",
+			rate:    100,
+			timeout: 45 * time.Millisecond,
+			wantErr: context.DeadlineExceeded,
+			wantMin: 1,
+			wantMax: 9,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := createCache(t, 20)
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			start := time.Now()
+			n, err := c.Warm(ctx, m, 0, tt.rate)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error, got %v, want %v", err, tt.wantErr)
+			}
+			if n < tt.wantMin || n > tt.wantMax || c.Len() != n {
+				t.Errorf("unexpected added count, got %v, length %v, want between %v and %v", n, c.Len(), tt.wantMin, tt.wantMax)
+			}
+			if elapsed := time.Since(start); elapsed < tt.wantMinTime {
+				t.Errorf("expected warm up to take at least %v, took %v", tt.wantMinTime, elapsed)
+			}
+			if counts := c.SourceCounts(); counts["warmup"] != n {
+				t.Errorf("unexpected source counts, got %v", counts)
+			}
+		})
+	}
+}
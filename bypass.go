@@ -0,0 +1,51 @@
+package cache
+
+import "context"
+
+// contextMode is how the calls passed a context with Context use the cache.
+type contextMode int
+
+const (
+	// modeNoStore skips storing the values, but they are still retrieved.
+	modeNoStore contextMode = 1 << iota
+
+	// modeBypass skips both retrieving and storing the values.
+	modeBypass
+)
+
+// modeKey is the key of the contextMode in a context.
+type modeKey struct{}
+
+// Bypass returns a copy of ctx which makes the calls passed it with Context
+// skip the cache entirely: Get doesn't find any key, and Add and GetOrLoad
+// don't store anything. It is meant for debugging sessions and cache-busting
+// request headers, propagated through the context to every cache on the path
+// of a request.
+func Bypass(ctx context.Context) context.Context {
+	return withMode(ctx, modeBypass)
+}
+
+// NoStore returns a copy of ctx which makes the calls passed it with Context
+// retrieve values as usual, but Add and GetOrLoad don't store the values
+// computed for the request.
+func NoStore(ctx context.Context) context.Context {
+	return withMode(ctx, modeNoStore)
+}
+
+// withMode returns a copy of ctx which adds mode to the mode of ctx.
+func withMode(ctx context.Context, mode contextMode) context.Context {
+	old, _ := ctx.Value(modeKey{}).(contextMode)
+	return context.WithValue(ctx, modeKey{}, old|mode)
+}
+
+// Context applies the flags set with Bypass and NoStore on ctx to the call.
+// Calls without it use the cache as usual. The pprof labels of ctx are kept
+// while the read repair function or the loader runs, see WithName.
+func Context(ctx context.Context) CallOption {
+	mode, _ := ctx.Value(modeKey{}).(contextMode)
+	return func(cfg *callConfig) {
+		cfg.ctx = ctx
+		cfg.bypass = cfg.bypass || mode&modeBypass != 0
+		cfg.noStore = cfg.noStore || mode&(modeBypass|modeNoStore) != 0
+	}
+}
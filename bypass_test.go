@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantFound bool
+		wantAdded bool
+		wantLoads int
+		wantStats Stats
+	}{
+		{
+			name:      "uses cache without flags",
+			ctx:       context.Background(),
+			wantFound: true,
+			wantAdded: true,
+			wantLoads: 1,
+			wantStats: Stats{Hits: 2, Misses: 1, Loads: 1},
+		},
+		{
+			name:      "retrieves but does not store with no-store",
+			ctx:       NoStore(context.Background()),
+			wantFound: true,
+			wantAdded: false,
+			wantLoads: 2,
+			wantStats: Stats{Hits: 1, Misses: 2, Loads: 2},
+		},
+		{
+			name:      "skips cache with bypass",
+			ctx:       Bypass(context.Background()),
+			wantFound: false,
+			wantAdded: false,
+			wantLoads: 2,
+			wantStats: Stats{Loads: 2},
+		},
+		{
+			name:      "keeps bypass of parent context",
+			ctx:       NoStore(Bypass(context.Background())),
+			wantFound: false,
+			wantAdded: false,
+			wantLoads: 2,
+			wantStats: Stats{Loads: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := createCache(t, 5)
+			addItems(t, c, [][]any{{k, v}})
+			if _, found := c.Get(k, Context(tt.ctx)); found != tt.wantFound {
+				t.Errorf("cache.Get() found = %v, want %v", found, tt.wantFound)
+			}
+			if err := c.Add(k+k, v, 0, Context(tt.ctx)); err != nil {
+				t.Fatalf("unexpected error, got %v", err)
+			}
+			if added := c.Contains(k + k); added != tt.wantAdded {
+				t.Errorf("cache.Contains() found = %v, want %v", added, tt.wantAdded)
+			}
+
+			loads := 0
+			loader := func(key any) (any, error) {
+				loads++
+				return v, nil
+			}
+			for i := 0; i < 2; i++ {
+				if _, err := c.GetOrLoad("loaded", 0, loader, Context(tt.ctx)); err != nil {
+					t.Fatalf("unexpected error, got %v", err)
+				}
+			}
+			if loads != tt.wantLoads {
+				t.Errorf("unexpected loads, got %v, want %v", loads, tt.wantLoads)
+			}
+			got := c.Stats()
+			got.LoadTime = 0
+			if got != tt.wantStats {
+				t.Errorf("unexpected stats, got %+v, want %+v", got, tt.wantStats)
+			}
+		})
+	}
+}
@@ -2,7 +2,7 @@ package cache
 
 import (
 	"container/list"
-	"sync"
+	"math/rand"
 	"time"
 )
 
@@ -14,11 +14,174 @@ type Cache struct {
 	// cap is the maximum capacity of the cache.
 	cap int
 
-	// mu is the mutex variable to prevent race conditions.
-	mu sync.Mutex
+	// janitor removes the expired items periodically. It is nil if the
+	// expired items are removed only manually.
+	janitor *janitor
+
+	// emergency evicts items when the heap is too big. It is nil if the
+	// emergency eviction is disabled.
+	emergency *emergency
+
+	// name tells the cache apart in CPU profiles.
+	name string
+
+	// scanIdx keeps the items by insertion order for ScanKeys. It is nil
+	// until ScanKeys is called.
+	scanIdx *scanIndex
+
+	// mu is the lock to prevent race conditions. Its behaviour is set by
+	// WithLockMode.
+	mu locker
 
 	// lst is the doubly-linked list that stores the cached data.
 	lst *list.List
+
+	// nsFn derives the namespace of a key. All keys are in the "" namespace
+	// if it is nil.
+	nsFn func(key interface{}) string
+
+	// gens keeps the current generation of each namespace.
+	gens map[string]uint64
+
+	// nsLen keeps the number of items in each namespace.
+	nsLen map[string]int
+
+	// quotas keeps the maximum number of items of the namespaces that are
+	// limited by WithNamespaceQuota.
+	quotas map[string]int
+
+	// stats keeps the counters of the whole cache.
+	stats Stats
+
+	// nsStats keeps the counters of the namespaces registered with
+	// WithNamespaceStats or WithNamespaceQuota.
+	nsStats map[string]*Stats
+
+	// admit decides whether an item is stored on Add. All items are admitted
+	// if it is nil.
+	admit func(key, val interface{}, exp time.Duration) bool
+
+	// validator checks the values written to the cache.
+	validator func(key, val interface{}) error
+
+	// repair is called when Get finds an expired item.
+	repair RepairFunc
+
+	// codecs transform the snapshots written by Save and read by Load.
+	codecs []Codec
+
+	// valueCodecs transform the values stored in the cache.
+	valueCodecs []Codec
+
+	// ttlRules set the expiration duration of the items added without one.
+	ttlRules []TTLRule
+
+	// idle is the idle timeout of the items added without one.
+	idle time.Duration
+
+	// defaultTTL is the expiration duration of the items added without one
+	// whose key matches no TTL rule.
+	defaultTTL time.Duration
+
+	// maxCost is the limit of the total cost of the items set with
+	// WithMaxBytes or WithMaxCost, and cost is the total cost. The cost of an
+	// item is its size unless weighted is true, when it is passed to
+	// AddWithCost. The size of an item is returned by sizeFn if it is not nil.
+	// evictedCost is the total cost of the evicted items, see AddReport.
+	maxCost     int64
+	cost        int64
+	evictedCost int64
+	weighted    bool
+	sizeFn      func(key, val interface{}) int64
+
+	// tombs remembers why the latest removed items are removed. It is nil if
+	// the misses are not classified.
+	tombs *ghostList
+
+	// lifetimes records the expiration durations and the lifetimes of the
+	// items. It is nil if the recording is disabled.
+	lifetimes *lifetimes
+
+	// tuner records the data for recommending a capacity. It is nil if the
+	// recording is disabled.
+	tuner *tuner
+
+	// audit keeps the sampled eviction records. It is nil if the audit is
+	// disabled.
+	audit *audit
+
+	// shadow is the cache the operations are mirrored into.
+	shadow *Cache
+
+	// hot detects the frequently accessed keys. It is nil if the detection
+	// is disabled.
+	hot *hotKeys
+
+	// card estimates the number of distinct keys retrieved with Get. It is
+	// nil if the estimation is disabled.
+	card *cardinality
+
+	// slowFn is called with the operations taking at least slowAfter. Slow
+	// operations are not reported if it is nil.
+	slowFn    func(SlowOp)
+	slowAfter time.Duration
+
+	// checkAll enables the verification of the invariants after every
+	// change.
+	checkAll bool
+
+	// compactItems disables recording the creation time, the access time and the
+	// hits of the items, see WithCompactItems.
+	compactItems bool
+
+	// noPromote disables moving items to the front of the list on Get.
+	noPromote bool
+
+	// policy selects the item evicted when the cache is full. samples is the
+	// number of items sampled by PolicySampled.
+	policy  EvictionPolicy
+	samples int
+
+	// protectedRatio is the share of the capacity kept for the protected
+	// segment of PolicySLRU. protectedLen is the number of protected items.
+	protectedRatio float64
+	protectedLen   int
+
+	// onEvict is called with the removed items after the lock is released.
+	// evictedItems keeps the items removed while the lock is held.
+	onEvict      func(key, val interface{})
+	evictedItems []Item
+
+	// flight coalesces the concurrent loads of GetOrLoad.
+	flight flight
+
+	// loadSlots limits the loaders running at the same time, see
+	// WithMaxConcurrentLoads. Loaders are not limited if it is nil.
+	loadSlots chan struct{}
+
+	// refresh loads fresh values for the stale items. refreshing keeps the
+	// keys being refreshed.
+	refresh    func(key interface{}) (interface{}, error)
+	refreshing map[interface{}]struct{}
+
+	// panicFn is called with the recovered panics. Panics are not recovered
+	// if it is nil.
+	panicFn func(*PanicError)
+
+	// parent is the cache whose capacity is shared, if created by NewChild.
+	parent *Cache
+
+	// share is the fraction of the capacity of the parent used as the
+	// capacity of the cache, if created by NewChild.
+	share float64
+
+	// children are the caches created by NewChild which are not closed. They
+	// are resized with the cache.
+	children []*Cache
+
+	// budget is the capacity shared with the parent or the children. It is
+	// nil if the cache shares its capacity with no other cache.
+	budget *budget
 }
 
 // Item is the cached data type.
@@ -31,11 +194,50 @@ type Item struct {
 
 	// Expiration is the amount of time to saved on memory.
 	Expiration int64
+
+	// Created is the time when the item is added to the cache, in Unix
+	// nanoseconds. It is 0 if the cache is created with WithCompactItems.
+	Created int64
+
+	// Accessed is the time when the item is last added or retrieved with
+	// Get, in Unix nanoseconds. It is 0 if the cache is created with
+	// WithCompactItems, unless the item has an idle timeout.
+	Accessed int64
+
+	// Hits is the number of times the item is retrieved with Get. It is 0
+	// if the cache is created with WithCompactItems.
+	Hits uint64
+
+	// IdleTimeout is how long the item lives after it is last added or
+	// retrieved with Get. The item expires when either the expiration or the
+	// idle timeout passes. It is 0 if the item never expires due to idleness.
+	IdleTimeout time.Duration
+
+	// Cost is the size of the item in bytes, counted against the limit set
+	// with WithMaxBytes, or the cost passed to AddWithCost, counted against
+	// the limit set with WithMaxCost. It is 0 if there is no limit.
+	Cost int64
+
+	// SoftExpiration is the time when the item becomes stale, in Unix
+	// nanoseconds, set with the SoftTTL call option. A stale item is still
+	// served until it expires. It is 0 if the item never becomes stale.
+	SoftExpiration int64
+
+	// Source is the code path which populated the item, set with the Source
+	// call option. GetOrLoad sets it to "loader".
+	Source string
+
+	// gen is the generation of the item's namespace when it was added.
+	gen uint64
+
+	// protected is true if the item is in the protected segment of
+	// PolicySLRU.
+	protected bool
 }
 
 // New creates a new cache and returns it with error type. Capacity of the cache
-// needs to be more than zero.
-func New(cap int) (*Cache, error) {
+// needs to be more than zero. Optional behaviour can be configured with opts.
+func New(cap int, opts ...Option) (*Cache, error) {
 	if cap == 0 {
 		return nil, errZeroCapacity
 	}
@@ -43,68 +245,207 @@ func New(cap int) (*Cache, error) {
 		return nil, errNegCapacity
 	}
 	lst := list.New()
-	return &Cache{
-		cap: cap,
-		mu:  sync.Mutex{},
-		lst: lst,
-	}, nil
+	c := &Cache{
+		cap:     cap,
+		mu:      &mutexLocker{},
+		lst:     lst,
+		gens:    make(map[string]uint64),
+		nsLen:   make(map[string]int),
+		nsStats: make(map[string]*Stats),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	for ns := range c.quotas {
+		if c.nsStats[ns] == nil {
+			c.nsStats[ns] = &Stats{}
+		}
+	}
+	// These features rely on the creation or the access times of the items.
+	if c.policy == PolicySampled || c.repair != nil || c.refresh != nil || c.audit != nil || c.lifetimes != nil {
+		c.compactItems = false
+	}
+	// Lockers are wrapped after applying all options, since WithLockMode
+	// replaces the locker.
+	if c.slowFn != nil {
+		c.timeFuncs()
+		c.mu = &timedLocker{locker: c.mu, c: c}
+	}
+	if c.checkAll {
+		c.mu = &checkedLocker{locker: c.mu, c: c}
+	}
+	if c.onEvict != nil {
+		c.mu = &evictLocker{locker: c.mu, c: c}
+	}
+	if c.janitor != nil {
+		c.startJanitor()
+	}
+	if c.emergency != nil {
+		c.startEmergency()
+	}
+	return c, nil
 }
 
-// Add saves data to cache if it is not saved yet. If the capacity is full,
-// the least-recently used one will be removed and new data will be added.
-// If you do not want to add an expired time for data, you need to pass 0.
-func (c *Cache) Add(key interface{}, val interface{}, exp time.Duration) error {
-	_, found := c.get(key)
-	if found {
-		return errKeyExist
+// Add saves data to cache if it is not saved yet or it is expired, otherwise
+// it returns ErrKeyExists and keeps the existing item. If the capacity is
+// full, the least-recently used one will be removed and new data will be
+// added, unless NoEvictOthers is passed. The item also expires if it
+// is not retrieved with Get for the duration passed with ExpireAfterAccess or
+// set with WithExpireAfterAccess.
+// If you do not want to add an expired time for data, you need to pass 0,
+// unless the key matches a rule set with WithTTLRules or a default is set with
+// WithDefaultTTL.
+// If the cache is created with WithAdmitFunc and the item is not admitted,
+// it returns ErrNotAdmitted and the item is not saved. Values rejected by the
+// validator set with WithValidator are not saved and a *ValidationError is
+// returned.
+// Nothing is saved if the context passed with Context is marked with Bypass or
+// NoStore.
+func (c *Cache) Add(key interface{}, val interface{}, exp time.Duration, opts ...CallOption) (err error) {
+	defer recoverCorrupt(&err)
+	cfg := newCallConfig(opts)
+	if cfg.noStore {
+		return nil
 	}
-	item := Item{
-		Key:        key,
-		Val:        val,
-		Expiration: time.Now().Add(exp).UnixNano(),
+	exp = c.ttlOf(key, exp)
+	if err := c.accept(key, val, exp); err != nil {
+		return err
 	}
-	if exp == 0 {
-		item.Expiration = 0
+	if c.shadow != nil {
+		defer c.shadow.Add(key, val, exp, mirrored(opts)...)
+	}
+	val, err = c.encodeVal(val)
+	if err != nil {
+		return err
 	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	if c.Len() == c.Cap() {
-		lruKey := c.getLRU()
-		c.delete(lruKey.Key)
+	if cfg.report != nil {
+		defer c.reportAdd(cfg.report)()
 	}
-
-	c.lst.PushFront(item)
-	c.len++
-	return nil
+	if cfg.noEvict {
+		// Replacing an expired item of the key reuses its slot and its cost.
+		if e, found := c.get(key); found {
+			if !mustItem(e).Expired() {
+				return ErrKeyExists
+			}
+			c.remove(e)
+		}
+		if !c.hasRoomFor(key) || !c.hasCostFor(key, val, cfg) {
+			return ErrCacheFull
+		}
+	}
+	return c.add(key, val, exp, cfg)
 }
 
 // Get retrieves the data from list and returns it with bool information which
 // indicates whether found. If there is no such data in cache, it returns nil
-// and false.
-func (c *Cache) Get(key interface{}) (interface{}, bool) {
-	if c.Len() == 0 {
+// and false. Expired items are removed and reported as not found, unless the
+// cache is created with WithReadRepair. The item becomes the most recently
+// used one unless the cache is created with WithGetDoesNotPromote or an
+// eviction policy other than PolicyLRU and PolicySLRU, or NoPromote is passed.
+// No key is found if the context passed with Context is marked with Bypass,
+// and the call is not counted in Stats.
+func (c *Cache) Get(key interface{}, opts ...CallOption) (val interface{}, found bool) {
+	cfg := newCallConfig(opts)
+	if cfg.bypass {
 		return nil, false
 	}
+	defer c.hot.notify()
+	if c.shadow != nil {
+		defer func() { c.mirrorGet(key, val, found, opts) }()
+	}
+	return c.decoded(c.getVal(key, cfg))
+}
+
+// getVal retrieves the value of the key for Get. The item is promoted unless
+// NoPromote is passed in cfg or promotion is disabled for the cache. The
+// settings of the cache are read while holding the lock, since Reconfigure
+// may change them concurrently.
+func (c *Cache) getVal(key interface{}, cfg callConfig) (interface{}, bool) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-	val, found := c.get(key)
-	if val == nil {
-		return nil, found
+	// The lock is released before read repair, and by the deferred call
+	// otherwise, even if the list is corrupt.
+	locked := true
+	defer func() {
+		if locked {
+			c.mu.Unlock()
+		}
+	}()
+	promote := !c.noPromote && c.reorders() && !cfg.noPromote
+	e, found := c.get(key)
+	if found && c.repair != nil && mustItem(e).Expired() {
+		stale := mustItem(e)
+		locked = false
+		c.mu.Unlock()
+		val, found := c.readRepair(cfg.ctx, stale, promote)
+		// A repaired item is as fresh as a loaded value.
+		cfg.serve(FromCache, 0)
+		return val, found
+	}
+	if found && mustItem(e).Expired() {
+		c.remove(e)
+		found = false
 	}
-	e := c.lst.Remove(val)
-	c.lst.PushFront(e)
-	return val.Value.(Item).Val, found
+	if !found {
+		c.miss(key)
+		return nil, false
+	}
+	if item := mustItem(e); item.SoftExpired() {
+		c.staleHit(key)
+		cfg.serve(FromStale, item.Created)
+		if c.refresh != nil {
+			c.startRefresh(item)
+		}
+	} else {
+		cfg.serve(FromCache, item.Created)
+	}
+	return c.access(e, promote), true
+}
+
+// peek returns the item of the key without changing the cache.
+func (c *Cache) peek(key interface{}) (Item, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, found := c.lookup(key)
+	if !found {
+		return Item{}, false
+	}
+	return mustItem(e), true
+}
+
+// snapshot returns the items which are not invalidated and pass keep, from
+// the most recently used one, or from the least recently used one if
+// fromBack is true.
+func (c *Cache) snapshot(fromBack bool, keep func(item Item) bool) []Item {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	items := make([]Item, 0, c.len)
+	next, e := (*list.Element).Next, c.lst.Front()
+	if fromBack {
+		next, e = (*list.Element).Prev, c.lst.Back()
+	}
+	for ; e != nil; e = next(e) {
+		if item := mustItem(e); !c.stale(item) && keep(item) {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // Remove deletes the item from the cache. Updates the length of the cache
 // decrementing by one.
-func (c *Cache) Remove(key interface{}) error {
-	if c.Len() == 0 {
-		return errEmptyCache
+func (c *Cache) Remove(key interface{}) (err error) {
+	defer recoverCorrupt(&err)
+	if c.shadow != nil {
+		defer c.shadow.Remove(key)
 	}
-
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if c.len == 0 {
+		return errEmptyCache
+	}
+
 	c.delete(key)
 	return nil
 }
@@ -113,56 +454,116 @@ func (c *Cache) Remove(key interface{}) error {
 // on cache or not. Calling this function doesn't change the access order of
 // the cache.
 func (c *Cache) Contains(key interface{}) bool {
-	if c.Len() == 0 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.len == 0 {
 		return false
 	}
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	_, found := c.get(key)
+	_, found := c.lookup(key)
 	return found
 }
 
 // Clear deletes all items from the cache.
 func (c *Cache) Clear() {
+	if c.shadow != nil {
+		defer c.shadow.Clear()
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.clear()
 }
 
+// ClearAfter clears the items in cache gradually over d, instead of all at
+// once like Clear, so a full invalidation doesn't cause every key to miss at
+// the same moment. Each item expires at a random time within d, unless it
+// expires earlier anyway, and is removed lazily like other expired items.
+// Items added after ClearAfter are not affected. If d is not positive, it
+// calls Clear.
+func (c *Cache) ClearAfter(d time.Duration) {
+	if d <= 0 {
+		c.Clear()
+		return
+	}
+	if c.shadow != nil {
+		defer c.shadow.ClearAfter(d)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now().UnixNano()
+	for e := c.lst.Front(); e != nil; e = e.Next() {
+		item := mustItem(e)
+		if at := now + rand.Int63n(int64(d)) + 1; item.Expiration == 0 || at < item.Expiration {
+			item.Expiration = at
+			setItem(e, item)
+		}
+	}
+}
+
 // Keys returns all keys in cache. It does not change frequency of the item
-// access.
+// access. Keys are ordered from the most recently used to the least recently
+// used one. The returned slice is a snapshot, later changes to the cache do
+// not affect it.
 func (c *Cache) Keys() []interface{} {
 	var keys []interface{}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	for e := c.lst.Front(); e != nil; e = e.Next() {
-		keys = append(keys, e.Value.(Item).Key)
+		if item := mustItem(e); !c.stale(item) {
+			keys = append(keys, item.Key)
+		}
 	}
 
 	return keys
 }
 
+// Range calls f for each item in cache, from the most recently used to the
+// least recently used one, until f returns false. It iterates over a snapshot
+// taken when Range is called, so f may modify the cache and concurrent
+// changes are not observed. It does not change frequency of the item access.
+func (c *Cache) Range(f func(key, val interface{}) bool) {
+	items := c.snapshot(false, func(item Item) bool { return true })
+	for _, item := range items {
+		val, ok := c.decoded(item.Val, true)
+		if !ok {
+			continue
+		}
+		if !f(item.Key, val) {
+			return
+		}
+	}
+}
+
 // Peek returns the given key without updating access frequency of the item.
 func (c *Cache) Peek(key interface{}) (interface{}, bool) {
-	if c.Len() == 0 {
-		return nil, false
-	}
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	val, found := c.get(key)
-	if !found {
-		return nil, found
+	item, found := c.PeekWithInfo(key)
+	return item.Val, found
+}
+
+// PeekWithInfo returns the item of the given key with its metadata, such as
+// expiration, creation time and hit count. Like Peek, it does not update
+// access frequency of the item. The creation time and the hit count are 0
+// if the cache is created with WithCompactItems.
+func (c *Cache) PeekWithInfo(key interface{}) (Item, bool) {
+	item, found := c.peek(key)
+	if item.Val, found = c.decoded(item.Val, found); !found {
+		return Item{}, false
 	}
-	return val.Value.(Item).Val, found
+	return item, true
 }
 
 // RemoveOldest removes the least recently used one. Returns removed key, value,
 // and bool value that indicates whether remove operation is done successfully.
 func (c *Cache) RemoveOldest() (k interface{}, v interface{}, ok bool) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	k, v, ok = c.removeOldest()
+	func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		k, v, ok = c.removeOldest()
+	}()
+	if ok {
+		// The item is removed even if its value can't be decoded.
+		v, _ = c.decoded(v, ok)
+	}
 	return
 }
 
@@ -171,6 +572,7 @@ func (c *Cache) RemoveOldest() (k interface{}, v interface{}, ok bool) {
 // of the removed oldest elements from the cache. If it is zero, means that
 // no data removed from the cache.
 func (c *Cache) Resize(size int) int {
+	defer c.resizeChildren()
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	diff := c.resize(size)
@@ -179,38 +581,48 @@ func (c *Cache) Resize(size int) int {
 
 // Len returns length of the cache.
 func (c *Cache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.len
 }
 
 // Cap returns capacity of the cache.
 func (c *Cache) Cap() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.cap
 }
 
 // Replace changes the value of the given key, if the key exists. If the key
 // does not exist, it returns error. Calling Replace function does not change
-// the cache order.
-func (c *Cache) Replace(key interface{}, val interface{}) error {
+// the cache order. Values rejected by the validator set with WithValidator
+// are not saved and a *ValidationError is returned. If the size of the cache
+// is limited, it returns ErrTooLarge or ErrUnknownSize and keeps the old
+// value when the new one doesn't fit, see WithMaxBytes.
+func (c *Cache) Replace(key interface{}, val interface{}) (err error) {
+	defer recoverCorrupt(&err)
+	if err := c.validate(key, val); err != nil {
+		return err
+	}
+	val, err = c.encodeVal(val)
+	if err != nil {
+		return err
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	e, found := c.get(key)
 	if !found {
 		return errKeyNotExist
 	}
-	e.Value = Item{
-		Key:        key,
-		Val:        val,
-		Expiration: e.Value.(Item).Expiration,
-	}
-	return nil
+	_, err = c.setVal(e, val)
+	return err
 }
 
 // ClearExpiredData deletes the all expired data in cache.
 func (c *Cache) ClearExpiredData() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	l := c.Len()
-	if l == 0 {
+	if c.len == 0 {
 		return
 	}
 
@@ -219,9 +631,18 @@ func (c *Cache) ClearExpiredData() {
 }
 
 // UpdateVal updates the value of the given key. If there is no such a data, error
-// will be returned. Cache data order is updated after updating the value. It
-// returns updated item.
-func (c *Cache) UpdateVal(key interface{}, val interface{}) (Item, error) {
+// will be returned. Cache data order is updated after updating the value,
+// only if the cache is created with PolicyLRU or PolicySLRU. It returns
+// updated item.
+func (c *Cache) UpdateVal(key interface{}, val interface{}) (item Item, err error) {
+	defer recoverCorrupt(&err)
+	if err := c.validate(key, val); err != nil {
+		return Item{}, err
+	}
+	val, err = c.encodeVal(val)
+	if err != nil {
+		return Item{}, err
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	return c.update(key, val, -1)
@@ -229,46 +650,288 @@ func (c *Cache) UpdateVal(key interface{}, val interface{}) (Item, error) {
 
 // UpdateExpirationDate updates the expiration date of the given key. If there
 // is no such a data, error will be returned. Cache data order is updated after
-// updating the expiration time. It returns updated item.
-func (c *Cache) UpdateExpirationDate(key interface{}, exp time.Duration) (Item, error) {
+// updating the expiration time, only if the cache is created with PolicyLRU
+// or PolicySLRU. It returns updated item.
+func (c *Cache) UpdateExpirationDate(key interface{}, exp time.Duration) (item Item, err error) {
+	defer recoverCorrupt(&err)
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	newExpTime := time.Now().Add(exp).Unix()
+	newExpTime := time.Now().Add(exp).UnixNano()
 	return c.update(key, nil, newExpTime)
 }
 
 // Expired returns true if the item expired.
 func (i Item) Expired() bool {
-	if i.Expiration == 0 {
-		return false
+	return i.expiredAt(time.Now().UnixNano())
+}
+
+// ExpiresAt returns the time when the item expires, which is the earlier of
+// the expiration and the end of the idle timeout. It returns zero time if the
+// item never expires.
+func (i Item) ExpiresAt() time.Time {
+	at := i.expiresAt()
+	if at == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, at)
+}
+
+// RemainingTTL returns the duration until the item expires. It returns 0 if
+// the item is already expired and -1 if the item never expires.
+func (i Item) RemainingTTL() time.Duration {
+	if i.expiresAt() == 0 {
+		return -1
+	}
+	if ttl := time.Until(i.ExpiresAt()); ttl > 0 {
+		return ttl
+	}
+	return 0
+}
+
+// expiresAt returns the time when the item expires in Unix nanoseconds, or 0
+// if the item never expires.
+func (i Item) expiresAt() int64 {
+	at := i.Expiration
+	if i.IdleTimeout != 0 {
+		if idle := i.Accessed + int64(i.IdleTimeout); at == 0 || idle < at {
+			at = idle
+		}
 	}
-	return i.Expiration < time.Now().UnixNano()
+	return at
+}
+
+// expiredAt reports whether the item is expired at the given time in Unix
+// nanoseconds.
+func (i Item) expiredAt(now int64) bool {
+	at := i.expiresAt()
+	return at != 0 && at < now
+}
+
+// validate checks the value with the validator set with WithValidator.
+func (c *Cache) validate(key, val interface{}) error {
+	if c.validator == nil {
+		return nil
+	}
+	if err := c.validator(key, val); err != nil {
+		return &ValidationError{Key: key, Err: err}
+	}
+	return nil
 }
 
 // get traverses the list from head to tail and looks at the given key at each
-// step. It can be considered data retrieve function for cache.
+// step. It can be considered data retrieve function for cache. Items that are
+// invalidated by BumpNamespace are removed when they are found.
 func (c *Cache) get(key interface{}) (*list.Element, bool) {
 	for e := c.lst.Front(); e != nil; e = e.Next() {
-		if e.Value.(Item).Key == key {
+		if item := mustItem(e); item.Key == key {
+			if c.stale(item) {
+				c.remove(e)
+				c.tombs.push(key, missInvalidated)
+				return nil, false
+			}
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// lookup is the read-only variant of get. Items that are invalidated by
+// BumpNamespace are reported as not found, but they are not removed.
+func (c *Cache) lookup(key interface{}) (*list.Element, bool) {
+	for e := c.lst.Front(); e != nil; e = e.Next() {
+		if item := mustItem(e); item.Key == key {
+			if c.stale(item) {
+				return nil, false
+			}
 			return e, true
 		}
 	}
 	return nil, false
 }
 
+// accept checks whether the value can be added to the cache with the validator
+// and the admission function.
+func (c *Cache) accept(key, val interface{}, exp time.Duration) error {
+	if err := c.validate(key, val); err != nil {
+		return err
+	}
+	if c.admit != nil && !c.admit(key, val, exp) {
+		return ErrNotAdmitted
+	}
+	return nil
+}
+
+// add saves the data to the cache, making room for it if the capacity is
+// full. It replaces the existing item of the key only if it is expired. If
+// the idle timeout of cfg is 0, the one set with WithExpireAfterAccess is
+// used.
+func (c *Cache) add(key interface{}, val interface{}, exp time.Duration, cfg callConfig) error {
+	if e, found := c.get(key); found {
+		if !mustItem(e).Expired() {
+			return ErrKeyExists
+		}
+		c.remove(e)
+	}
+	idle := cfg.idle
+	if idle == 0 {
+		idle = c.idle
+	}
+	now := time.Now()
+	item := Item{
+		Key:         key,
+		Val:         val,
+		Expiration:  now.Add(exp).UnixNano(),
+		IdleTimeout: idle,
+		Source:      cfg.source,
+		Cost:        cfg.cost,
+		gen:         c.gens[c.namespace(key)],
+	}
+	if !c.compactItems {
+		item.Created = now.UnixNano()
+	}
+	if !c.compactItems || idle != 0 {
+		item.Accessed = now.UnixNano()
+	}
+	if c.weighted && !cfg.hasCost {
+		item.Cost = 1
+	}
+	if exp == 0 {
+		item.Expiration = 0
+	}
+	if cfg.soft != 0 {
+		item.SoftExpiration = now.Add(cfg.soft).UnixNano()
+	}
+	c.lifetimes.assigned(exp)
+	return c.put(item)
+}
+
+// put saves the item to the cache, making room for it if the capacity is
+// full. The item becomes the most recently used one.
+func (c *Cache) put(item Item) error {
+	cost, err := c.costOf(item)
+	if err != nil {
+		return err
+	}
+	item.Cost = cost
+	c.reserveQuota(c.namespace(item.Key))
+	if !c.reserve() {
+		return ErrNoBudget
+	}
+	c.fitCost(cost, nil)
+
+	c.insert(item)
+	return nil
+}
+
+// access records a hit for the element and promotes it if promote is true. It
+// returns the value of the element.
+func (c *Cache) access(e *list.Element, promote bool) interface{} {
+	if c.tuner != nil {
+		c.tuner.hit(c.position(e))
+	}
+	item := mustItem(e)
+	c.hit(item.Key)
+	if !c.compactItems {
+		item.Hits++
+	}
+	if !c.compactItems || item.IdleTimeout != 0 {
+		item.Accessed = time.Now().UnixNano()
+	}
+	setItem(e, item)
+	if promote {
+		c.lst.MoveToFront(e)
+		c.protect(e)
+	}
+	return item.Val
+}
+
+// insert pushes the item to the front of the list and updates the length of
+// the cache.
+func (c *Cache) insert(item Item) *list.Element {
+	c.tombs.drop(item.Key)
+	c.len++
+	c.cost += item.Cost
+	c.nsLen[c.namespace(item.Key)]++
+	e := c.lst.PushFront(newNode(item))
+	c.scanIdx.add(e)
+	return e
+}
+
+// remove removes the element from the list and updates the length of the
+// cache. The lifetime of the item is recorded if it is expired.
+func (c *Cache) remove(e *list.Element) {
+	item := mustItem(e)
+	c.lifetimes.removed(item, false)
+	if c.tombs != nil && item.Expired() {
+		c.tombs.push(item.Key, missExpired)
+	}
+	c.unlink(e)
+}
+
+// unlink removes the element from the list and updates the length of the
+// cache.
+func (c *Cache) unlink(e *list.Element) {
+	c.lst.Remove(e)
+	c.len--
+	item := mustItem(e)
+	c.scanIdx.remove(e)
+	c.cost -= item.Cost
+	c.evicted(item)
+	if item.protected {
+		c.protectedLen--
+	}
+	ns := c.namespace(item.Key)
+	if c.nsLen[ns]--; c.nsLen[ns] == 0 {
+		delete(c.nsLen, ns)
+	}
+	c.budget.release()
+}
+
+// hasRoomFor reports whether the item of the key can be added without evicting
+// others, considering the quota of its namespace as well.
+func (c *Cache) hasRoomFor(key interface{}) bool {
+	ns := c.namespace(key)
+	if max, ok := c.quotas[ns]; ok && c.nsLen[ns] >= max {
+		return false
+	}
+	return c.hasRoom()
+}
+
+// hasRoom reports whether a new item can be added without evicting others.
+func (c *Cache) hasRoom() bool {
+	return c.len < c.cap && c.budget.available()
+}
+
+// reserve makes room for a new item. If the capacity is full, or the budget
+// shared with the parent or children is exhausted, the least recently used
+// items are removed. It returns false if there is no item left to remove.
+func (c *Cache) reserve() bool {
+	if c.len == c.cap {
+		c.evictOldest(EvictCapacity)
+	}
+	for !c.budget.reserve() {
+		if !c.evictOldest(EvictBudget) {
+			return false
+		}
+	}
+	return true
+}
+
 // delete removes the cached data from the list.
 func (c *Cache) delete(key interface{}) {
 	v, found := c.get(key)
 	if !found {
 		return
 	}
-	c.lst.Remove(v)
-	c.len--
+	if c.tombs != nil && !mustItem(v).Expired() {
+		c.tombs.push(key, missInvalidated)
+	}
+	c.remove(v)
 }
 
 // getLRU returns least recently used item from list.
 func (c *Cache) getLRU() Item {
-	return c.lst.Back().Value.(Item)
+	return mustItem(c.lst.Back())
 }
 
 // clear removes all elements from the list.
@@ -276,14 +939,13 @@ func (c *Cache) clear() {
 	var next *list.Element
 	for e := c.lst.Front(); e != nil; e = next {
 		next = e.Next()
-		c.lst.Remove(e)
-		c.len--
+		c.remove(e)
 	}
 }
 
 // removeOldest removes the oldest data from the cache.
 func (c *Cache) removeOldest() (key interface{}, val interface{}, ok bool) {
-	if c.Len() == 0 {
+	if c.len == 0 {
 		return "", nil, false
 	}
 	oldest := c.getLRU()
@@ -294,18 +956,28 @@ func (c *Cache) removeOldest() (key interface{}, val interface{}, ok bool) {
 }
 
 // resize changes the capacity of the cache. It prunes the oldest elements from
-// the cache if the size is lower than length of the cache.
+// the cache if the size is lower than length of the cache. If the cache is the
+// parent of other caches, the shared budget is resized as well and the oldest
+// elements of the parent are pruned until the budget fits.
 func (c *Cache) resize(size int) int {
 	var diff int
-	if size < c.Len() {
-		diff = c.Len() - size
+	if size < c.len {
+		diff = c.len - size
 	}
 
 	for i := 0; i < diff; i++ {
-		c.removeOldest()
+		c.evictOldest(EvictResize)
 	}
 	c.cap = size
 
+	if c.budget != nil && c.parent == nil {
+		c.budget.setCap(size)
+		for c.len > 0 && c.budget.exceeds(size) {
+			c.evictOldest(EvictResize)
+			diff++
+		}
+	}
+
 	return diff
 }
 
@@ -314,35 +986,31 @@ func (c *Cache) clearExpiredData(now int64) {
 	var next *list.Element
 	for e := c.lst.Front(); e != nil; e = next {
 		next = e.Next()
-		if exp := e.Value.(Item).Expiration; exp != 0 && exp < now {
-			c.lst.Remove(e)
-			c.len--
+		if mustItem(e).expiredAt(now) {
+			c.remove(e)
 		}
 	}
 }
 
 // update changes the val and/or expiration date.
 func (c *Cache) update(key interface{}, val interface{}, exp int64) (Item, error) {
-	var next *list.Element
-	for e := c.lst.Front(); e != nil; e = next {
-		next = e.Next()
-		if k := e.Value.(Item).Key; k == key {
-			if val == nil {
-				val = e.Value.(Item).Val
-			}
-			if exp == -1 {
-				exp = e.Value.(Item).Expiration
-			}
-
-			c.lst.Remove(e)
-			newItem := Item{
-				Key:        key,
-				Val:        val,
-				Expiration: exp,
-			}
-			c.lst.PushFront(newItem)
-			return newItem, nil
+	e, found := c.get(key)
+	if !found {
+		return Item{}, errNoKey
+	}
+	if val != nil {
+		if _, err := c.setVal(e, val); err != nil {
+			return Item{}, err
 		}
 	}
-	return Item{}, errNoKey
+	newItem := mustItem(e)
+	if exp != -1 {
+		newItem.Expiration = exp
+	}
+
+	setItem(e, newItem)
+	if c.reorders() {
+		c.lst.MoveToFront(e)
+	}
+	return newItem, nil
 }
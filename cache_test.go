@@ -55,12 +55,7 @@ func addItemsWithExp(t *testing.T, cache *Cache, pairs [][]any) {
 // function to prevent code duplication.
 func findItem(t *testing.T, c *Cache, key any) (Item, bool) {
 	t.Helper()
-	for e := c.lst.Front(); e != nil; e = e.Next() {
-		if item := e.Value.(Item); item.Key == key {
-			return item, true
-		}
-	}
-	return Item{}, false
+	return c.PeekWithInfo(key)
 }
 
 // cmpCacheListOrder compares the order of the items (reflecting the access frequency
@@ -75,7 +70,7 @@ func cmpCacheListOrder(t *testing.T, c *Cache, order []any) {
 	e := c.lst.Front()
 	for e != nil {
 		o := order[i]
-		k := e.Value.(Item).Key
+		k := mustItem(e).Key
 		if !reflect.DeepEqual(k, o) {
 			t.Errorf("incorrect key order, got %v, want %v at index %d", k, o, i)
 		}
@@ -127,7 +122,7 @@ func TestCache_Add(t *testing.T) {
 					t.Errorf("unexpected error, got error %v, want %v", err, wantErr)
 					return
 				}
-				if exp := c.lst.Front().Value.(Item).Expiration; exp != wantExp {
+				if exp := mustItem(c.lst.Front()).Expiration; exp != wantExp {
 					t.Errorf("unexpected expiration, got %v want %v", exp, wantExp)
 				}
 			}
@@ -252,6 +247,34 @@ func TestCache_Get(t *testing.T) {
 	}
 }
 
+func TestCache_AddExpired(t *testing.T) {
+	c := createCache(t, 2)
+	addItemsWithExp(t, c, [][]any{{k, v, -1 * time.Hour}})
+	if err := c.Add(k, v+v, 0); err != nil {
+		t.Errorf("unexpected error adding expired key, got %v", err)
+	}
+	if got, found := c.Get(k); !found || got != v+v {
+		t.Errorf("cache.Get() = %v, %v, want %v, %v", got, found, v+v, true)
+	}
+	if c.Len() != 1 {
+		t.Errorf("unexpected length, got %v, want %v", c.Len(), 1)
+	}
+}
+
+func TestCache_AddExisting(t *testing.T) {
+	c := createCache(t, 2)
+	addItems(t, c, [][]any{{k, v}})
+	if err := c.Add(k, v+v, 0); !errors.Is(err, ErrKeyExists) {
+		t.Errorf("unexpected error adding existing key, got %v, want %v", err, ErrKeyExists)
+	}
+	if got, found := c.Get(k); !found || got != v {
+		t.Errorf("cache.Get() = %v, %v, want %v, %v", got, found, v, true)
+	}
+	if c.Len() != 1 {
+		t.Errorf("unexpected length, got %v, want %v", c.Len(), 1)
+	}
+}
+
 func TestCache_Remove(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -422,7 +445,7 @@ func TestCache_Clear(t *testing.T) {
 			}
 			if tt.checkListFront {
 				if c.lst.Front() != nil {
-					f := c.lst.Front().Value.(Item)
+					f := mustItem(c.lst.Front())
 					t.Errorf("expected c.lst.Front() to be nil, got %s-%s", f.Key, f.Val)
 				}
 			}
@@ -430,6 +453,31 @@ func TestCache_Clear(t *testing.T) {
 	}
 }
 
+func TestCache_ClearAfter(t *testing.T) {
+	c := createCache(t, 50)
+	for i := 0; i < 40; i++ {
+		addItemsWithExp(t, c, [][]any{{i, v, time.Duration(0)}})
+	}
+	addItemsWithExp(t, c, [][]any{{k, v, time.Millisecond}})
+	c.ClearAfter(100 * time.Millisecond)
+	addItemsWithExp(t, c, [][]any{{k + k, v, time.Duration(0)}})
+
+	time.Sleep(50 * time.Millisecond)
+	c.ClearExpiredData()
+	// The items expire at random times, so it is practically impossible
+	// that all or none of them expire in the first half of the window.
+	if n := c.Len(); n <= 1 || n >= 41 {
+		t.Errorf("expected items to be cleared gradually, got length %v", n)
+	}
+	if c.Contains(k) {
+		t.Errorf("expected item to keep its earlier expiration")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	c.ClearExpiredData()
+	cmpCacheListOrder(t, c, []any{k + k})
+}
+
 func TestCache_Keys(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -466,6 +514,72 @@ func TestCache_Keys(t *testing.T) {
 	}
 }
 
+func TestCache_Range(t *testing.T) {
+	tests := []struct {
+		name       string
+		capacity   int
+		addPairs   [][]any
+		getKeys    []any
+		stopAfter  int
+		removeKeys bool
+		wantKeys   []any
+	}{
+		{
+			name:      "does not call f for empty cache",
+			capacity:  1,
+			addPairs:  [][]any{},
+			stopAfter: -1,
+			wantKeys:  nil,
+		},
+		{
+			name:      "iterates from most recently used to least recently used",
+			capacity:  3,
+			addPairs:  [][]any{{k, v}, {k + k, v + v}, {k + k + k, v + v + v}},
+			getKeys:   []any{k + k},
+			stopAfter: -1,
+			wantKeys:  []any{k + k, k + k + k, k},
+		},
+		{
+			name:      "stops when f returns false",
+			capacity:  3,
+			addPairs:  [][]any{{k, v}, {k + k, v + v}, {k + k + k, v + v + v}},
+			stopAfter: 2,
+			wantKeys:  []any{k + k + k, k + k},
+		},
+		{
+			name:       "iterates over snapshot when f removes items",
+			capacity:   3,
+			addPairs:   [][]any{{k, v}, {k + k, v + v}, {k + k + k, v + v + v}},
+			stopAfter:  -1,
+			removeKeys: true,
+			wantKeys:   []any{k + k + k, k + k, k},
+		},
+	}
+	for _, tt := range tests {
+		c := createCache(t, tt.capacity)
+		addItems(t, c, tt.addPairs)
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range tt.getKeys {
+				c.Get(key)
+			}
+			var got []any
+			c.Range(func(key, val any) bool {
+				got = append(got, key)
+				if tt.removeKeys {
+					_ = c.Remove(key)
+				}
+				return len(got) != tt.stopAfter
+			})
+			if !reflect.DeepEqual(got, tt.wantKeys) {
+				t.Errorf("unexpected iteration order, got %v, want %v", got, tt.wantKeys)
+			}
+			if tt.removeKeys && c.Len() != 0 {
+				t.Errorf("unexpected length after removing keys, got %v, want %v", c.Len(), 0)
+			}
+		})
+	}
+}
+
 func TestCache_Peek(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -527,6 +641,86 @@ func TestCache_Peek(t *testing.T) {
 	}
 }
 
+func TestCache_PeekWithInfo(t *testing.T) {
+	tests := []struct {
+		name              string
+		capacity          int
+		addPairs          [][]any
+		getKeys           []any
+		peekKey           any
+		wantFound         bool
+		wantVal           any
+		wantHits          uint64
+		wantExpiration    bool
+		wantKeysListOrder []any
+	}{
+		{
+			name:              "returns empty item for empty cache",
+			capacity:          1,
+			addPairs:          [][]any{},
+			peekKey:           k,
+			wantFound:         false,
+			wantKeysListOrder: nil,
+		},
+		{
+			name:              "returns item without expiration",
+			capacity:          3,
+			addPairs:          [][]any{{k, v, time.Duration(0)}, {k + k, v + v, time.Duration(0)}},
+			peekKey:           k,
+			wantFound:         true,
+			wantVal:           v,
+			wantExpiration:    false,
+			wantKeysListOrder: []any{k + k, k},
+		},
+		{
+			name:              "returns item with expiration and hit count",
+			capacity:          3,
+			addPairs:          [][]any{{k, v, time.Hour}, {k + k, v + v, time.Hour}},
+			getKeys:           []any{k + k, k + k, k},
+			peekKey:           k + k,
+			wantFound:         true,
+			wantVal:           v + v,
+			wantHits:          2,
+			wantExpiration:    true,
+			wantKeysListOrder: []any{k, k + k},
+		},
+	}
+	for _, tt := range tests {
+		c := createCache(t, tt.capacity)
+		addItemsWithExp(t, c, tt.addPairs)
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range tt.getKeys {
+				c.Get(key)
+			}
+			got, found := c.PeekWithInfo(tt.peekKey)
+			if found != tt.wantFound {
+				t.Errorf("cache.PeekWithInfo() found = %v, want %v", found, tt.wantFound)
+			}
+			if !found {
+				if !reflect.DeepEqual(got, Item{}) {
+					t.Errorf("cache.PeekWithInfo() = %v, want empty item", got)
+				}
+				return
+			}
+			if got.Key != tt.peekKey || got.Val != tt.wantVal {
+				t.Errorf("unexpected item, got %v-%v, want %v-%v", got.Key, got.Val, tt.peekKey, tt.wantVal)
+			}
+			if got.Hits != tt.wantHits {
+				t.Errorf("unexpected hits, got %v, want %v", got.Hits, tt.wantHits)
+			}
+			if (got.Expiration != 0) != tt.wantExpiration {
+				t.Errorf("unexpected expiration, got %v", got.Expiration)
+			}
+			if got.Created == 0 {
+				t.Errorf("expected creation time to be set")
+			}
+			if tt.wantKeysListOrder != nil {
+				cmpCacheListOrder(t, c, tt.wantKeysListOrder)
+			}
+		})
+	}
+}
+
 func TestCache_RemoveOldest(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -932,6 +1126,9 @@ func TestCache_UpdateExpirationDate(t *testing.T) {
 				if wantErr == nil && newItem.Expiration == oldItem.Expiration {
 					t.Errorf("expected updated item expiration time %v, got %v", oldItem.Expiration, newItem.Expiration)
 				}
+				if ttl := newItem.RemainingTTL(); wantErr == nil && (ttl <= 0 || ttl > duration) {
+					t.Errorf("unexpected updated item remaining ttl, got %v, want at most %v", ttl, duration)
+				}
 			}
 			if tt.wantKeysListOrder != nil {
 				cmpCacheListOrder(t, c, tt.wantKeysListOrder)
@@ -985,3 +1182,113 @@ func TestItem_NotExpired(t *testing.T) {
 		}
 	}
 }
+
+func TestItem_ExpiresAt(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		item Item
+		want time.Time
+	}{
+		{
+			name: "returns zero time when expiration = 0",
+			item: Item{Key: k, Val: v, Expiration: 0},
+			want: time.Time{},
+		},
+		{
+			name: "returns expiration time",
+			item: Item{Key: k, Val: v, Expiration: now.UnixNano()},
+			want: now,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.item.ExpiresAt(); !got.Equal(tt.want) {
+				t.Errorf("unexpected expiration time, got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestItem_RemainingTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		wantMin  time.Duration
+		wantMax  time.Duration
+	}{
+		{
+			name:     "returns -1 when expiration = 0",
+			duration: 0,
+			wantMin:  -1,
+			wantMax:  -1,
+		},
+		{
+			name:     "returns 0 for expired item",
+			duration: -1 * time.Hour,
+			wantMin:  0,
+			wantMax:  0,
+		},
+		{
+			name:     "returns remaining duration for unexpired item",
+			duration: time.Hour,
+			wantMin:  time.Hour - time.Minute,
+			wantMax:  time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := createCache(t, 1)
+			addItemsWithExp(t, c, [][]any{{k, v, tt.duration}})
+			item, _ := findItem(t, c, k)
+			if got := item.RemainingTTL(); got < tt.wantMin || got > tt.wantMax {
+				t.Errorf("unexpected remaining ttl, got %v, want between %v and %v", got, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestItem_ExpiredIdle(t *testing.T) {
+	now := time.Now().UnixNano()
+	tests := []struct {
+		name        string
+		item        Item
+		wantExpired bool
+		wantAt      int64
+	}{
+		{
+			name:        "expires after idle timeout",
+			item:        Item{Accessed: now - int64(time.Hour), IdleTimeout: time.Minute},
+			wantExpired: true,
+			wantAt:      now - int64(time.Hour) + int64(time.Minute),
+		},
+		{
+			name:        "does not expire within idle timeout",
+			item:        Item{Accessed: now, IdleTimeout: time.Hour},
+			wantExpired: false,
+			wantAt:      now + int64(time.Hour),
+		},
+		{
+			name:        "expires at expiration before idle timeout",
+			item:        Item{Expiration: now + int64(time.Minute), Accessed: now, IdleTimeout: time.Hour},
+			wantExpired: false,
+			wantAt:      now + int64(time.Minute),
+		},
+		{
+			name:        "expires at idle timeout before expiration",
+			item:        Item{Expiration: now + int64(time.Hour), Accessed: now, IdleTimeout: time.Minute},
+			wantExpired: false,
+			wantAt:      now + int64(time.Minute),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.item.Expired(); got != tt.wantExpired {
+				t.Errorf("item.Expired() = %v, want %v", got, tt.wantExpired)
+			}
+			if got := tt.item.ExpiresAt().UnixNano(); got != tt.wantAt {
+				t.Errorf("item.ExpiresAt() = %v, want %v", got, tt.wantAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package cachetest
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// concurrencyCap is the capacity of the caches created by
+// RunConcurrencySuite. It is smaller than the number of keys, so the
+// operations evict items concurrently as well.
+const concurrencyCap = 64
+
+// RunConcurrencySuite hammers caches created by factory with concurrent mixed
+// operations and checks that they are still consistent afterwards. It finds
+// data races only when the tests run with the race detector, e.g.
+// "go test -race". factory is called with the capacity of the cache to
+// create. If the cache has a Healthy() error method, like *cache.Cache, it
+// is called at the end of each test as well.
+func RunConcurrencySuite(t *testing.T, factory func(cap int) Cache) {
+	t.Helper()
+	tests := []struct {
+		name       string
+		goroutines int
+		ops        int
+		keys       int
+		kinds      []OpKind
+	}{
+		{
+			name:       "mixed operations",
+			goroutines: 8,
+			ops:        2000,
+			keys:       4 * concurrencyCap,
+			kinds:      []OpKind{OpAdd, OpGet, OpRemove, OpContains, OpPeek},
+		},
+		{
+			name:       "read heavy",
+			goroutines: 16,
+			ops:        2000,
+			keys:       concurrencyCap,
+			kinds:      []OpKind{OpAdd, OpGet, OpGet, OpGet, OpContains, OpPeek, OpPeek},
+		},
+		{
+			name:       "write heavy with evictions",
+			goroutines: 8,
+			ops:        2000,
+			keys:       16 * concurrencyCap,
+			kinds:      []OpKind{OpAdd, OpAdd, OpAdd, OpRemove},
+		},
+		{
+			name:       "contended hot keys",
+			goroutines: 16,
+			ops:        2000,
+			keys:       4,
+			kinds:      []OpKind{OpAdd, OpGet, OpRemove},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := factory(concurrencyCap)
+			var wg sync.WaitGroup
+			for g := 0; g < tt.goroutines; g++ {
+				wg.Add(1)
+				go func(seed int64) {
+					defer wg.Done()
+					r := rand.New(rand.NewSource(seed))
+					for i := 0; i < tt.ops; i++ {
+						op := Op{Kind: tt.kinds[r.Intn(len(tt.kinds))], Key: r.Intn(tt.keys), Val: i}
+						Apply(c, op)
+						if i%100 == 0 {
+							c.Keys()
+							c.Len()
+						}
+					}
+				}(int64(g))
+			}
+			wg.Wait()
+			if err := checkConsistent(c, concurrencyCap); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// checkConsistent returns error if the cache holds more items than its
+// capacity, its length disagrees with its keys or a key is stored twice.
+func checkConsistent(c Cache, cap int) error {
+	keys := c.Keys()
+	if len(keys) > cap {
+		return fmt.Errorf("cache holds %d keys, more than capacity %d", len(keys), cap)
+	}
+	if c.Len() != len(keys) {
+		return fmt.Errorf("cache length %d does not match %d keys", c.Len(), len(keys))
+	}
+	seen := make(map[interface{}]struct{}, len(keys))
+	for _, key := range keys {
+		if _, dup := seen[key]; dup {
+			return fmt.Errorf("key %v is stored twice", key)
+		}
+		seen[key] = struct{}{}
+	}
+	if h, ok := c.(interface{ Healthy() error }); ok {
+		return h.Healthy()
+	}
+	return nil
+}
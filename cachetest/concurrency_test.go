@@ -0,0 +1,30 @@
+package cachetest
+
+import (
+	"testing"
+
+	"github.com/gozeloglu/cache"
+)
+
+func TestRunConcurrencySuite(t *testing.T) {
+	modes := []struct {
+		name string
+		mode cache.LockMode
+	}{
+		{name: "exclusive", mode: cache.LockExclusive},
+		{name: "write preferring", mode: cache.LockWritePreferring},
+		{name: "read preferring", mode: cache.LockReadPreferring},
+		{name: "fair", mode: cache.LockFair},
+	}
+	for _, m := range modes {
+		t.Run(m.name, func(t *testing.T) {
+			RunConcurrencySuite(t, func(cap int) Cache {
+				c, err := cache.New(cap, cache.WithLockMode(m.mode))
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+				return c
+			})
+		})
+	}
+}
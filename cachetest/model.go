@@ -0,0 +1,247 @@
+// Package cachetest provides utilities for verifying cache implementations.
+// Operation sequences are replayed against a cache and a simple reference
+// model of the LRU cache of package cache, and the results are compared after
+// every operation. Alternative policies or backends with the same behaviour
+// can be verified the same way.
+package cachetest
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"time"
+
+	"github.com/gozeloglu/cache"
+)
+
+// Cache is the part of the cache API verified by Replay. *cache.Cache
+// implements it.
+type Cache interface {
+	Add(key interface{}, val interface{}, exp time.Duration, opts ...cache.CallOption) error
+	Get(key interface{}, opts ...cache.CallOption) (interface{}, bool)
+	Remove(key interface{}) error
+	Contains(key interface{}) bool
+	Peek(key interface{}) (interface{}, bool)
+	Keys() []interface{}
+	Len() int
+}
+
+// OpKind is the kind of an operation.
+type OpKind int
+
+const (
+	// OpAdd adds the key with the value, without expiration.
+	OpAdd OpKind = iota
+
+	// OpGet retrieves the key.
+	OpGet
+
+	// OpRemove removes the key.
+	OpRemove
+
+	// OpContains checks whether the key exists.
+	OpContains
+
+	// OpPeek retrieves the key without changing the access order.
+	OpPeek
+
+	numOpKinds
+)
+
+// String returns the name of the operation kind.
+func (k OpKind) String() string {
+	switch k {
+	case OpAdd:
+		return "Add"
+	case OpGet:
+		return "Get"
+	case OpRemove:
+		return "Remove"
+	case OpContains:
+		return "Contains"
+	case OpPeek:
+		return "Peek"
+	default:
+		return fmt.Sprintf("OpKind(%d)", int(k))
+	}
+}
+
+// Op is an operation on a cache.
+type Op struct {
+	Kind OpKind
+	Key  interface{}
+	Val  interface{}
+}
+
+// String returns the operation in the form of a method call.
+func (op Op) String() string {
+	if op.Kind == OpAdd {
+		return fmt.Sprintf("Add(%v, %v)", op.Key, op.Val)
+	}
+	return fmt.Sprintf("%v(%v)", op.Kind, op.Key)
+}
+
+// Result is the observable result of an operation. Errors are compared only
+// by whether they occurred.
+type Result struct {
+	Val   interface{}
+	Found bool
+	Err   bool
+}
+
+// Apply applies the operation to the cache and returns its result.
+func Apply(c Cache, op Op) Result {
+	switch op.Kind {
+	case OpAdd:
+		return Result{Err: c.Add(op.Key, op.Val, 0) != nil}
+	case OpGet:
+		val, found := c.Get(op.Key)
+		return Result{Val: val, Found: found}
+	case OpRemove:
+		return Result{Err: c.Remove(op.Key) != nil}
+	case OpContains:
+		return Result{Found: c.Contains(op.Key)}
+	case OpPeek:
+		val, found := c.Peek(op.Key)
+		return Result{Val: val, Found: found}
+	default:
+		panic(fmt.Sprintf("cachetest: unknown operation %v", op.Kind))
+	}
+}
+
+// Model is the reference model of an LRU cache. It keeps the keys in a slice
+// from the most recently used to the least recently used one, which is slow
+// but obviously correct.
+type Model struct {
+	cap  int
+	keys []interface{}
+	vals map[interface{}]interface{}
+}
+
+// NewModel creates an empty model with the given capacity.
+func NewModel(cap int) *Model {
+	return &Model{cap: cap, vals: make(map[interface{}]interface{})}
+}
+
+// Apply applies the operation to the model and returns its result.
+func (m *Model) Apply(op Op) Result {
+	_, exists := m.vals[op.Key]
+	switch op.Kind {
+	case OpAdd:
+		if exists {
+			return Result{Err: true}
+		}
+		if len(m.keys) == m.cap {
+			delete(m.vals, m.keys[len(m.keys)-1])
+			m.keys = m.keys[:len(m.keys)-1]
+		}
+		m.keys = append([]interface{}{op.Key}, m.keys...)
+		m.vals[op.Key] = op.Val
+		return Result{}
+	case OpGet:
+		if !exists {
+			return Result{}
+		}
+		m.moveToFront(op.Key)
+		return Result{Val: m.vals[op.Key], Found: true}
+	case OpRemove:
+		// Removing a missing key fails only if the cache is empty.
+		if !exists {
+			return Result{Err: len(m.keys) == 0}
+		}
+		i := m.index(op.Key)
+		m.keys = append(m.keys[:i], m.keys[i+1:]...)
+		delete(m.vals, op.Key)
+		return Result{}
+	case OpContains:
+		return Result{Found: exists}
+	case OpPeek:
+		return Result{Val: m.vals[op.Key], Found: exists}
+	default:
+		panic(fmt.Sprintf("cachetest: unknown operation %v", op.Kind))
+	}
+}
+
+// Keys returns the keys from the most recently used to the least recently
+// used one.
+func (m *Model) Keys() []interface{} {
+	keys := make([]interface{}, len(m.keys))
+	copy(keys, m.keys)
+	return keys
+}
+
+// index returns the position of the key in the access order.
+func (m *Model) index(key interface{}) int {
+	for i, k := range m.keys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// moveToFront makes the key the most recently used one.
+func (m *Model) moveToFront(key interface{}) {
+	i := m.index(key)
+	copy(m.keys[1:i+1], m.keys[:i])
+	m.keys[0] = key
+}
+
+// Replay applies the operations to the cache and the model in order. After
+// every operation, it compares the results, the lengths and the key orders,
+// and returns error describing the first difference. The cache and the model
+// must have the same capacity and be empty.
+func Replay(c Cache, m *Model, ops []Op) error {
+	for i, op := range ops {
+		got, want := Apply(c, op), m.Apply(op)
+		if !reflect.DeepEqual(got, want) {
+			return fmt.Errorf("op %d %v: got %+v, want %+v", i, op, got, want)
+		}
+		if got, want := c.Len(), len(m.keys); got != want {
+			return fmt.Errorf("op %d %v: got length %d, want %d", i, op, got, want)
+		}
+		if got, want := c.Keys(), m.Keys(); !equalKeys(got, want) {
+			return fmt.Errorf("op %d %v: got keys %v, want %v", i, op, got, want)
+		}
+	}
+	return nil
+}
+
+// equalKeys reports whether the key orders are equal. Empty and nil slices
+// are equal.
+func equalKeys(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// RandomOps returns n random operations on keys in [0, keys). Values are
+// derived from the position of the operation, so each Add writes a distinct
+// value.
+func RandomOps(r *rand.Rand, n, keys int) []Op {
+	ops := make([]Op, n)
+	for i := range ops {
+		ops[i] = Op{Kind: OpKind(r.Intn(int(numOpKinds))), Key: r.Intn(keys), Val: i}
+	}
+	return ops
+}
+
+// OpsFromBytes decodes operations from data, two bytes per operation, so
+// fuzzers can generate operation sequences. Keys are in [0, keys).
+func OpsFromBytes(data []byte, keys int) []Op {
+	ops := make([]Op, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		ops = append(ops, Op{
+			Kind: OpKind(int(data[i]) % int(numOpKinds)),
+			Key:  int(data[i+1]) % keys,
+			Val:  i / 2,
+		})
+	}
+	return ops
+}
@@ -0,0 +1,73 @@
+package cachetest
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gozeloglu/cache"
+)
+
+func TestReplay(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		keys     int
+		ops      int
+	}{
+		{
+			name:     "capacity of one",
+			capacity: 1,
+			keys:     3,
+			ops:      1000,
+		},
+		{
+			name:     "keys fit in capacity",
+			capacity: 8,
+			keys:     4,
+			ops:      1000,
+		},
+		{
+			name:     "keys exceed capacity",
+			capacity: 4,
+			keys:     16,
+			ops:      5000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := cache.New(tt.capacity)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			ops := RandomOps(rand.New(rand.NewSource(1)), tt.ops, tt.keys)
+			if err := Replay(c, NewModel(tt.capacity), ops); err != nil {
+				t.Errorf("cache diverged from model: %v", err)
+			}
+		})
+	}
+}
+
+func TestReplay_Diverged(t *testing.T) {
+	c, err := cache.New(2, cache.WithGetDoesNotPromote())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	ops := []Op{{Kind: OpAdd, Key: 1}, {Kind: OpAdd, Key: 2}, {Kind: OpGet, Key: 1}}
+	if err := Replay(c, NewModel(2), ops); err == nil {
+		t.Errorf("expected cache without promotion to diverge from model")
+	}
+}
+
+func FuzzReplay(f *testing.F) {
+	f.Add([]byte{0, 1, 0, 2, 1, 1, 0, 3, 2, 2, 4, 1})
+	f.Add([]byte{0, 0, 0, 0, 2, 0, 2, 0, 3, 0})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c, err := cache.New(3)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if err := Replay(c, NewModel(3), OpsFromBytes(data, 5)); err != nil {
+			t.Errorf("cache diverged from model: %v", err)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package cachetest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gozeloglu/cache"
+)
+
+// stampedeCap is the capacity of the caches created by RunStampedeSuite. It
+// is bigger than the number of keys, so no loaded value is evicted.
+const stampedeCap = 256
+
+// LoadingCache is the part of the cache API exercised by RunStampedeSuite.
+// *cache.Cache implements it.
+type LoadingCache interface {
+	GetOrLoad(key interface{}, exp time.Duration, loader func(key interface{}) (interface{}, error), opts ...cache.CallOption) (interface{}, error)
+}
+
+// RunStampedeSuite simulates storms of misses on caches created by factory and
+// checks that the origin behind them is protected, i.e. each key is loaded at
+// most once per storm and every caller receives the loaded value. The
+// scenarios are a hot key requested by many callers at once, a cold start
+// where all keys are requested at once on an empty cache, and a mass expiry
+// where all keys expire together and are requested again. factory is called
+// with the capacity of the cache to create.
+func RunStampedeSuite(t *testing.T, factory func(cap int) LoadingCache) {
+	t.Helper()
+	tests := []struct {
+		name    string
+		keys    int
+		callers int
+		expire  bool
+	}{
+		{
+			name:    "hot key storm",
+			keys:    1,
+			callers: 64,
+		},
+		{
+			name:    "cold start",
+			keys:    32,
+			callers: 8,
+		},
+		{
+			name:    "mass expiry",
+			keys:    32,
+			callers: 8,
+			expire:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := factory(stampedeCap)
+			o := &origin{calls: make(map[interface{}]int)}
+			// Items of the first storm of mass expiry outlive it, but expire
+			// before the second one.
+			exp := time.Duration(0)
+			if tt.expire {
+				exp = 200 * time.Millisecond
+			}
+			o.storm(t, c, tt.keys, tt.callers, exp)
+			storms := 1
+			if tt.expire {
+				time.Sleep(exp)
+				o.storm(t, c, tt.keys, tt.callers, exp)
+				storms++
+			}
+			for key := 0; key < tt.keys; key++ {
+				if n := o.callsOf(key); n < 1 || n > storms {
+					t.Errorf("origin is called %d times for key %v in %d storms, want between 1 and %d", n, key, storms, storms)
+				}
+			}
+		})
+	}
+}
+
+// origin is a slow backend which counts its calls for each key.
+type origin struct {
+	mu    sync.Mutex
+	calls map[interface{}]int
+}
+
+// load returns the value of the key after a delay, which lets the callers of
+// a storm pile up while it runs.
+func (o *origin) load(key interface{}) (interface{}, error) {
+	o.mu.Lock()
+	o.calls[key]++
+	o.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	return key, nil
+}
+
+// callsOf returns the number of calls for the key.
+func (o *origin) callsOf(key interface{}) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.calls[key]
+}
+
+// storm requests each of the keys from the given number of callers at once.
+func (o *origin) storm(t *testing.T, c LoadingCache, keys, callers int, exp time.Duration) {
+	t.Helper()
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for key := 0; key < keys; key++ {
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func(key int) {
+				defer wg.Done()
+				<-start
+				val, err := c.GetOrLoad(key, exp, o.load)
+				if err != nil || val != key {
+					t.Errorf("GetOrLoad(%v) = %v, %v, want %v, %v", key, val, err, key, nil)
+				}
+			}(key)
+		}
+	}
+	close(start)
+	wg.Wait()
+}
@@ -0,0 +1,17 @@
+package cachetest
+
+import (
+	"testing"
+
+	"github.com/gozeloglu/cache"
+)
+
+func TestRunStampedeSuite(t *testing.T) {
+	RunStampedeSuite(t, func(cap int) LoadingCache {
+		c, err := cache.New(cap)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		return c
+	})
+}
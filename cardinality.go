@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// hllPrecision is the number of hash bits which pick the register of a
+// HyperLogLog sketch. 2^14 registers give a standard error of about 0.8%.
+const hllPrecision = 14
+
+// hll is a HyperLogLog sketch estimating the number of distinct hashes added
+// to it.
+type hll [1 << hllPrecision]uint8
+
+// add adds the hash to the sketch.
+func (h *hll) add(hash uint64) {
+	i := hash >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(hash<<hllPrecision|1<<(hllPrecision-1)) + 1)
+	if rank > h[i] {
+		h[i] = rank
+	}
+}
+
+// estimate returns the estimated number of distinct hashes added to the
+// sketch.
+func (h *hll) estimate() uint64 {
+	m := float64(len(h))
+	var sum float64
+	var zeros int
+	for _, r := range h {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	e := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		// Linear counting is more accurate for small cardinalities.
+		e = m * math.Log(m/float64(zeros))
+	}
+	return uint64(e + 0.5)
+}
+
+// hashKey returns a well mixed 64-bit hash of the key.
+func hashKey(key interface{}) uint64 {
+	f := fnv.New64a()
+	switch k := key.(type) {
+	case string:
+		f.Write([]byte(k))
+	default:
+		fmt.Fprintf(f, "%T:%v", key, key)
+	}
+	// The finalizer of SplitMix64 spreads the bits of FNV, whose high bits
+	// are poorly distributed for short keys.
+	h := f.Sum64()
+	h = (h ^ h>>30) * 0xbf58476d1ce4e5b9
+	h = (h ^ h>>27) * 0x94d049bb133111eb
+	return h ^ h>>31
+}
+
+// cardinality estimates the number of distinct keys retrieved with Get in
+// fixed windows.
+type cardinality struct {
+	mu     sync.Mutex
+	window int64
+
+	// start is the start of the current window in Unix nanoseconds. cur is
+	// the sketch of the current window and prev is the estimate of the
+	// previous one.
+	start int64
+	cur   *hll
+	prev  uint64
+}
+
+// WithCardinality estimates the number of distinct keys retrieved with Get,
+// including the misses, in fixed windows of the given duration. The estimate
+// of the latest complete window is reported as DistinctKeys in Stats, to help
+// sizing the cache relative to the working set. The estimate is off by about
+// 1% and it needs 16 KiB of memory. Non-positive windows are ignored.
+func WithCardinality(window time.Duration) Option {
+	return func(c *Cache) {
+		if window <= 0 {
+			return
+		}
+		c.card = &cardinality{window: int64(window), cur: new(hll)}
+	}
+}
+
+// observe adds the key to the sketch of the current window. A nil cardinality
+// ignores all keys.
+func (e *cardinality) observe(key interface{}, now int64) {
+	if e == nil {
+		return
+	}
+	hash := hashKey(key)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.roll(now)
+	e.cur.add(hash)
+}
+
+// roll starts a new window if the current one has ended. The estimate of the
+// current window is kept as the previous one only if the new window follows
+// it directly.
+func (e *cardinality) roll(now int64) {
+	if now-e.start < e.window {
+		return
+	}
+	e.prev = 0
+	if now-e.start < 2*e.window {
+		e.prev = e.cur.estimate()
+	}
+	e.start = now
+	e.cur = new(hll)
+}
+
+// previous returns the estimate of the previous window.
+func (e *cardinality) previous(now int64) uint64 {
+	if e == nil {
+		return 0
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.roll(now)
+	return e.prev
+}
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestHLL_Estimate(t *testing.T) {
+	tests := []struct {
+		name     string
+		distinct int
+		repeat   int
+	}{
+		{name: "empty", distinct: 0, repeat: 1},
+		{name: "small cardinality", distinct: 100, repeat: 3},
+		{name: "medium cardinality", distinct: 10000, repeat: 2},
+		{name: "large cardinality", distinct: 200000, repeat: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h hll
+			for r := 0; r < tt.repeat; r++ {
+				for i := 0; i < tt.distinct; i++ {
+					h.add(hashKey(fmt.Sprintf("key-%d", i)))
+				}
+			}
+			got := float64(h.estimate())
+			if want := float64(tt.distinct); got < want*0.97 || got > want*1.03 {
+				t.Errorf("unexpected estimate, got %v, want %v ± 3%%", got, want)
+			}
+		})
+	}
+}
+
+func TestWithCardinality(t *testing.T) {
+	const window = 100 * time.Millisecond
+	c, err := New(10, WithCardinality(window))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	addItems(t, c, [][]any{{k, v}})
+	for i := 0; i < 3; i++ {
+		c.Get(k)
+		c.Get(i)
+		c.Get(fmt.Sprint(i))
+	}
+	if got := c.Stats().DistinctKeys; got != 0 {
+		t.Errorf("unexpected distinct keys before window ends, got %v, want 0", got)
+	}
+	time.Sleep(window)
+	if got := c.Stats().DistinctKeys; got != 7 {
+		t.Errorf("unexpected distinct keys, got %v, want 7", got)
+	}
+	time.Sleep(window)
+	if got := c.Stats().DistinctKeys; got != 0 {
+		t.Errorf("unexpected distinct keys of empty window, got %v, want 0", got)
+	}
+}
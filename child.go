@@ -0,0 +1,151 @@
+package cache
+
+import "sync"
+
+// budget is the capacity shared by a parent cache and its children.
+type budget struct {
+	mu   sync.Mutex
+	cap  int
+	used int
+}
+
+// reserve takes one slot from the budget. It returns false if the budget is
+// exhausted. A nil budget is unlimited.
+func (b *budget) reserve() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.used >= b.cap {
+		return false
+	}
+	b.used++
+	return true
+}
+
+// release gives one slot back to the budget.
+func (b *budget) release() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used--
+}
+
+// available reports whether there is a free slot in the budget.
+func (b *budget) available() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used < b.cap
+}
+
+// exceeds reports whether more slots are used than the given size.
+func (b *budget) exceeds(size int) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used > size
+}
+
+// setCap changes the size of the budget.
+func (b *budget) setCap(size int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cap = size
+}
+
+// NewChild creates a cache that draws from the capacity of the parent. The
+// capacity of the parent becomes a budget shared by the parent and all of its
+// children, so they can't collectively hold more items than the parent's
+// capacity. maxShare limits the child to the given fraction of the budget and
+// must be in (0, 1]. The capacity of the child follows the capacity of the
+// parent when the parent is resized, until the child is closed.
+//
+// When the budget is exhausted, a cache makes room by evicting its own least
+// recently used item, it never evicts items of other caches. If it has no
+// items to evict, Add returns ErrNoBudget.
+func NewChild(parent *Cache, maxShare float64, opts ...Option) (*Cache, error) {
+	if maxShare <= 0 || maxShare > 1 {
+		return nil, errInvalidShare
+	}
+
+	parent.mu.Lock()
+	if parent.budget == nil {
+		parent.budget = &budget{cap: parent.cap, used: parent.len}
+	}
+	b := parent.budget
+	cap := shareOf(parent.cap, maxShare)
+	parent.mu.Unlock()
+
+	// The budget is installed before New starts the goroutines of the child,
+	// which remove items from it.
+	opts = append(opts[:len(opts):len(opts)], withParent(parent, b, maxShare))
+	c, err := New(cap, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	parent.mu.Lock()
+	parent.children = append(parent.children, c)
+	cap = shareOf(parent.cap, maxShare)
+	parent.mu.Unlock()
+	// The parent may be resized while the child is created.
+	if cap != c.Cap() {
+		c.Resize(cap)
+	}
+	return c, nil
+}
+
+// withParent makes the cache a child of parent drawing from the budget b with
+// the given share, see NewChild.
+func withParent(parent *Cache, b *budget, share float64) Option {
+	return func(c *Cache) {
+		c.parent = parent
+		c.budget = b
+		c.share = share
+	}
+}
+
+// shareOf returns the capacity of a child with the given share of size. It is
+// at least one.
+func shareOf(size int, share float64) int {
+	if cap := int(float64(size) * share); cap > 1 {
+		return cap
+	}
+	return 1
+}
+
+// resizeChildren resizes the children of the cache to their share of its
+// capacity. It is called without holding the lock of the cache, since the
+// children call their eviction callbacks while resizing.
+func (c *Cache) resizeChildren() {
+	c.mu.RLock()
+	children := c.children
+	size := c.cap
+	c.mu.RUnlock()
+	for _, child := range children {
+		if cap := shareOf(size, child.share); cap != child.Cap() {
+			child.Resize(cap)
+		}
+	}
+}
+
+// removeChild stops resizing the child with the cache.
+func (c *Cache) removeChild(child *Cache) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	children := make([]*Cache, 0, len(c.children))
+	for _, other := range c.children {
+		if other != child {
+			children = append(children, other)
+		}
+	}
+	c.children = children
+}
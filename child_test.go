@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewChild(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		share    float64
+		wantCap  int
+		wantErr  error
+	}{
+		{
+			name:     "returns error when share <= 0",
+			capacity: 10,
+			share:    0,
+			wantErr:  errInvalidShare,
+		},
+		{
+			name:     "returns error when share > 1",
+			capacity: 10,
+			share:    1.5,
+			wantErr:  errInvalidShare,
+		},
+		{
+			name:     "creates child with a fraction of parent capacity",
+			capacity: 10,
+			share:    0.25,
+			wantCap:  2,
+			wantErr:  nil,
+		},
+		{
+			name:     "creates child with capacity of at least one",
+			capacity: 10,
+			share:    0.01,
+			wantCap:  1,
+			wantErr:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := createCache(t, tt.capacity)
+			child, err := NewChild(parent, tt.share)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("cache.NewChild() error = %v, want %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if child.Cap() != tt.wantCap {
+				t.Errorf("unexpected child capacity, got %v, want %v", child.Cap(), tt.wantCap)
+			}
+		})
+	}
+}
+
+func TestNewChild_SharedBudget(t *testing.T) {
+	tests := []struct {
+		name              string
+		capacity          int
+		share             float64
+		parentPairs       [][]any
+		firstPairs        [][]any
+		secondPairs       [][]any
+		addParent         []any
+		wantErr           error
+		wantParentLength  int
+		wantFirstOrder    []any
+		wantSecondOrder   []any
+		resize            int
+		wantResizeRemoved int
+	}{
+		{
+			name:             "parent returns error when children use the whole budget",
+			capacity:         4,
+			share:            0.5,
+			firstPairs:       [][]any{{k, v}, {k + k, v + v}},
+			secondPairs:      [][]any{{k, v}, {k + k, v + v}},
+			addParent:        []any{k, v},
+			wantErr:          ErrNoBudget,
+			wantParentLength: 0,
+			wantFirstOrder:   []any{k + k, k},
+			wantSecondOrder:  []any{k + k, k},
+		},
+		{
+			name:             "child evicts its own items when the budget is exhausted",
+			capacity:         4,
+			share:            0.75,
+			firstPairs:       [][]any{{k, v}, {k + k, v + v}, {k + k + k, v + v + v}},
+			secondPairs:      [][]any{{k, v}, {k + k, v + v}},
+			wantParentLength: 0,
+			wantFirstOrder:   []any{k + k + k, k + k, k},
+			wantSecondOrder:  []any{k + k},
+		},
+		{
+			name:             "parent evicts its own items when the budget is exhausted",
+			capacity:         3,
+			share:            0.5,
+			parentPairs:      [][]any{{k, v}},
+			firstPairs:       [][]any{{k, v}},
+			secondPairs:      [][]any{{k, v}},
+			addParent:        []any{k + k, v + v},
+			wantErr:          nil,
+			wantParentLength: 1,
+			wantFirstOrder:   []any{k},
+			wantSecondOrder:  []any{k},
+		},
+		{
+			name:              "resizing parent prunes parent items until the budget fits",
+			capacity:          4,
+			share:             0.5,
+			parentPairs:       [][]any{{k, v}, {k + k, v + v}},
+			firstPairs:        [][]any{{k, v}},
+			secondPairs:       [][]any{{k, v}},
+			wantParentLength:  1,
+			wantFirstOrder:    []any{k},
+			wantSecondOrder:   []any{k},
+			resize:            3,
+			wantResizeRemoved: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := createCache(t, tt.capacity)
+			addItems(t, parent, tt.parentPairs)
+			first, err := NewChild(parent, tt.share)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			second, err := NewChild(parent, tt.share)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			addItems(t, first, tt.firstPairs)
+			addItems(t, second, tt.secondPairs)
+			if tt.addParent != nil {
+				err := parent.Add(tt.addParent[0], tt.addParent[1], 0)
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("unexpected error, got %v, want %v", err, tt.wantErr)
+				}
+			}
+			if tt.resize != 0 {
+				if got := parent.Resize(tt.resize); got != tt.wantResizeRemoved {
+					t.Errorf("unexpected removed count, got %v, want %v", got, tt.wantResizeRemoved)
+				}
+			}
+			if parent.Len() != tt.wantParentLength {
+				t.Errorf("unexpected parent length, got %v, want %v", parent.Len(), tt.wantParentLength)
+			}
+			cmpCacheListOrder(t, first, tt.wantFirstOrder)
+			cmpCacheListOrder(t, second, tt.wantSecondOrder)
+			if used := parent.budget.used; used > parent.Cap() {
+				t.Errorf("budget exceeded, used %v, capacity %v", used, parent.Cap())
+			}
+		})
+	}
+}
+
+func TestNewChild_FollowsParent(t *testing.T) {
+	parent := createCache(t, 10)
+	child, err := NewChild(parent, 0.5, WithJanitor(time.Millisecond))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	addItems(t, child, [][]any{{1, v}, {2, v}, {3, v}, {4, v}, {5, v}})
+
+	parent.Resize(4)
+	if child.Cap() != 2 || child.Len() != 2 {
+		t.Errorf("unexpected child after shrinking parent, got capacity %v and length %v, want %v and %v", child.Cap(), child.Len(), 2, 2)
+	}
+	cfg := parent.Config()
+	cfg.Capacity = 20
+	if err := parent.Reconfigure(cfg); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if child.Cap() != 10 {
+		t.Errorf("unexpected child capacity after growing parent, got %v, want %v", child.Cap(), 10)
+	}
+
+	child.Close()
+	parent.Resize(2)
+	if child.Cap() != 10 {
+		t.Errorf("expected closed child not to follow parent, got capacity %v, want %v", child.Cap(), 10)
+	}
+}
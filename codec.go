@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+)
+
+// Codec transforms snapshot streams, e.g. to compress or encrypt them.
+type Codec interface {
+	// Encoder returns a writer that encodes the data written to it and
+	// writes the result to w. The data must be complete after Close.
+	Encoder(w io.Writer) (io.WriteCloser, error)
+
+	// Decoder returns a reader that decodes the data read from r.
+	Decoder(r io.Reader) (io.Reader, error)
+}
+
+// WithSnapshotCodecs sets the codecs applied to snapshots written by Save and
+// read by Load. Codecs are applied in the given order on Save and in reverse
+// order on Load, e.g. WithSnapshotCodecs(GzipCodec(), aesCodec) compresses
+// and then encrypts snapshots.
+func WithSnapshotCodecs(codecs ...Codec) Option {
+	return func(c *Cache) {
+		c.codecs = codecs
+	}
+}
+
+// gzipCodec compresses data with gzip.
+type gzipCodec struct{}
+
+// GzipCodec returns a Codec that compresses data with gzip.
+func GzipCodec() Codec {
+	return gzipCodec{}
+}
+
+// Encoder returns a gzip writer.
+func (gzipCodec) Encoder(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+// Decoder returns a gzip reader.
+func (gzipCodec) Decoder(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+// aesCodec encrypts data with AES-GCM.
+type aesCodec struct {
+	aead cipher.AEAD
+}
+
+// NewAESCodec returns a Codec that encrypts and authenticates data with
+// AES-GCM. The key must be 16, 24 or 32 bytes long to select AES-128, AES-192
+// or AES-256. The whole data is buffered in memory since it is sealed at
+// once.
+func NewAESCodec(key []byte) (Codec, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &aesCodec{aead: aead}, nil
+}
+
+// Encoder returns a writer that seals the buffered data on Close and writes
+// the nonce followed by the ciphertext to w.
+func (a *aesCodec) Encoder(w io.Writer) (io.WriteCloser, error) {
+	return &sealWriter{aead: a.aead, w: w}, nil
+}
+
+// Decoder reads all data from r and opens it.
+func (a *aesCodec) Decoder(r io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	n := a.aead.NonceSize()
+	if len(data) < n {
+		return nil, errDecrypt
+	}
+	plain, err := a.aead.Open(nil, data[:n], data[n:], nil)
+	if err != nil {
+		return nil, errDecrypt
+	}
+	return bytes.NewReader(plain), nil
+}
+
+// sealWriter buffers the written data and seals it on Close.
+type sealWriter struct {
+	aead cipher.AEAD
+	w    io.Writer
+	buf  bytes.Buffer
+}
+
+// Write buffers p.
+func (s *sealWriter) Write(p []byte) (int, error) {
+	return s.buf.Write(p)
+}
+
+// Close seals the buffered data and writes it.
+func (s *sealWriter) Close() error {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	_, err := s.w.Write(s.aead.Seal(nonce, nonce, s.buf.Bytes(), nil))
+	return err
+}
+
+// encodeWriter chains the encoders of the codecs in front of w. Closing the
+// returned writer closes every encoder, but not w.
+func encodeWriter(w io.Writer, codecs []Codec) (io.WriteCloser, error) {
+	var closers []io.Closer
+	cur := w
+	for i := len(codecs) - 1; i >= 0; i-- {
+		enc, err := codecs[i].Encoder(cur)
+		if err != nil {
+			return nil, err
+		}
+		closers = append(closers, enc)
+		cur = enc
+	}
+	return &chainWriter{Writer: cur, closers: closers}, nil
+}
+
+// decodeReader chains the decoders of the codecs after r.
+func decodeReader(r io.Reader, codecs []Codec) (io.Reader, error) {
+	cur := r
+	for i := len(codecs) - 1; i >= 0; i-- {
+		dec, err := codecs[i].Decoder(cur)
+		if err != nil {
+			return nil, err
+		}
+		cur = dec
+	}
+	return cur, nil
+}
+
+// chainWriter writes to the innermost encoder of a chain and closes the
+// encoders from the innermost to the outermost one.
+type chainWriter struct {
+	io.Writer
+	closers []io.Closer
+}
+
+// Close closes the encoders in order.
+func (c *chainWriter) Close() error {
+	for i := len(c.closers) - 1; i >= 0; i-- {
+		if err := c.closers[i].Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
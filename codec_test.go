@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestWithSnapshotCodecs(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	otherKey := bytes.Repeat([]byte{2}, 32)
+	aesCodec, err := NewAESCodec(key)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	otherAESCodec, err := NewAESCodec(otherKey)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	tests := []struct {
+		name        string
+		saveCodecs  []Codec
+		loadCodecs  []Codec
+		wantErr     error
+		wantLoaded  int
+		wantEncoded bool
+	}{
+		{
+			name:       "restores snapshot without codecs",
+			wantLoaded: 3,
+		},
+		{
+			name:        "restores gzip compressed snapshot",
+			saveCodecs:  []Codec{GzipCodec()},
+			loadCodecs:  []Codec{GzipCodec()},
+			wantLoaded:  3,
+			wantEncoded: true,
+		},
+		{
+			name:        "restores encrypted snapshot",
+			saveCodecs:  []Codec{aesCodec},
+			loadCodecs:  []Codec{aesCodec},
+			wantLoaded:  3,
+			wantEncoded: true,
+		},
+		{
+			name:        "restores compressed and encrypted snapshot",
+			saveCodecs:  []Codec{GzipCodec(), aesCodec},
+			loadCodecs:  []Codec{GzipCodec(), aesCodec},
+			wantLoaded:  3,
+			wantEncoded: true,
+		},
+		{
+			name:        "returns error for wrong key",
+			saveCodecs:  []Codec{aesCodec},
+			loadCodecs:  []Codec{otherAESCodec},
+			wantErr:     errDecrypt,
+			wantEncoded: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(3, WithSnapshotCodecs(tt.saveCodecs...))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			addItems(t, c, [][]any{{k, v}, {k + k, v + v}, {k + k + k, v + v + v}})
+			var buf bytes.Buffer
+			if err := c.Save(&buf); err != nil {
+				t.Fatalf("unexpected error, got %v", err)
+			}
+			if encoded := !bytes.HasPrefix(buf.Bytes(), snapshotMagic[:]); encoded != tt.wantEncoded {
+				t.Errorf("unexpected encoding, got %v, want %v", encoded, tt.wantEncoded)
+			}
+
+			restored, err := New(3, WithSnapshotCodecs(tt.loadCodecs...))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			report, err := restored.Load(&buf)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error, got %v, want %v", err, tt.wantErr)
+			}
+			if report.Loaded != tt.wantLoaded {
+				t.Errorf("unexpected loaded count, got %v, want %v", report.Loaded, tt.wantLoaded)
+			}
+		})
+	}
+}
+
+func TestNewAESCodec(t *testing.T) {
+	if _, err := NewAESCodec([]byte("short")); err == nil {
+		t.Errorf("expected error for invalid key size")
+	}
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"container/list"
+	"time"
+)
+
+// Compact rebuilds the internal structures of the cache to release the
+// memory retained after large shrink or clear cycles. Expired items and items
+// invalidated by BumpNamespace are removed, the list is rebuilt with fresh
+// elements and the maps keeping per-namespace data are reallocated to their
+// current size, since Go maps never shrink. The access order is kept and the
+// removed items are not counted as evictions. It returns the number of
+// removed items.
+func (c *Cache) Compact() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.compact(time.Now().UnixNano())
+}
+
+// ScheduleCompact compacts the cache on a recurring schedule described by
+// spec. See ScheduleClear for the format of spec.
+//
+// It returns a stop function which cancels the schedule. Calling stop more
+// than once is safe.
+func (c *Cache) ScheduleCompact(spec string) (stop func(), err error) {
+	return c.runOnSchedule(spec, "compact", func() { c.Compact() })
+}
+
+// compact removes the items that are expired at now or invalidated, and
+// rebuilds the list and the maps.
+func (c *Cache) compact(now int64) int {
+	var next *list.Element
+	removed := 0
+	for e := c.lst.Front(); e != nil; e = next {
+		next = e.Next()
+		item := mustItem(e)
+		if c.stale(item) || item.expiredAt(now) {
+			c.remove(e)
+			removed++
+		}
+	}
+
+	lst := list.New()
+	for e := c.lst.Front(); e != nil; e = e.Next() {
+		c.scanIdx.move(e, lst.PushBack(e.Value))
+	}
+	c.lst = lst
+	c.scanIdx.shrink()
+
+	nsLen := make(map[string]int, len(c.nsLen))
+	for ns, n := range c.nsLen {
+		nsLen[ns] = n
+	}
+	c.nsLen = nsLen
+
+	// Generations of the namespaces without items can be dropped, since no
+	// item of an older generation is left to be invalidated.
+	gens := make(map[string]uint64)
+	for ns, gen := range c.gens {
+		if c.nsLen[ns] > 0 {
+			gens[ns] = gen
+		}
+	}
+	c.gens = gens
+	return removed
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache_Compact(t *testing.T) {
+	tests := []struct {
+		name              string
+		bumpBefore        string
+		addPairs          [][]any
+		bump              string
+		wantRemoved       int
+		wantKeysListOrder []any
+		wantGens          int
+	}{
+		{
+			name:              "keeps order of live items",
+			addPairs:          [][]any{{"a:1", v, time.Duration(0)}, {"b:1", v, time.Hour}, {"a:2", v, time.Duration(0)}},
+			wantRemoved:       0,
+			wantKeysListOrder: []any{"a:2", "b:1", "a:1"},
+		},
+		{
+			name:              "removes expired items",
+			addPairs:          [][]any{{"a:1", v, -time.Hour}, {"b:1", v, time.Hour}, {"a:2", v, -time.Hour}},
+			wantRemoved:       2,
+			wantKeysListOrder: []any{"b:1"},
+		},
+		{
+			name:              "removes invalidated items and drops their generation",
+			addPairs:          [][]any{{"a:1", v, time.Duration(0)}, {"b:1", v, time.Duration(0)}},
+			bump:              "a",
+			wantRemoved:       1,
+			wantKeysListOrder: []any{"b:1"},
+			wantGens:          0,
+		},
+		{
+			name:              "keeps generation of namespaces with items",
+			bumpBefore:        "a",
+			addPairs:          [][]any{{"a:1", v, time.Duration(0)}, {"b:1", v, time.Duration(0)}},
+			bump:              "b",
+			wantRemoved:       1,
+			wantKeysListOrder: []any{"a:1"},
+			wantGens:          1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(5, WithNamespace(PrefixNamespace(":")))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if tt.bumpBefore != "" {
+				c.BumpNamespace(tt.bumpBefore)
+			}
+			addItemsWithExp(t, c, tt.addPairs)
+			if tt.bump != "" {
+				c.BumpNamespace(tt.bump)
+			}
+			if got := c.Compact(); got != tt.wantRemoved {
+				t.Errorf("cache.Compact() = %v, want %v", got, tt.wantRemoved)
+			}
+			cmpCacheListOrder(t, c, tt.wantKeysListOrder)
+			if len(c.gens) != tt.wantGens {
+				t.Errorf("unexpected generations, got %v, want %v", len(c.gens), tt.wantGens)
+			}
+			if err := c.Healthy(); err != nil {
+				t.Errorf("unexpected error, got %v", err)
+			}
+		})
+	}
+}
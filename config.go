@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"fmt"
+	"path"
+	"time"
+)
+
+// Config holds the settings of a cache that can be changed at runtime with
+// Reconfigure.
+type Config struct {
+	// Capacity is the maximum number of items. Items over the capacity are
+	// evicted from the least recently used one, see Resize.
+	Capacity int
+
+	// DefaultTTL is the expiration duration of the items added with an
+	// expiration duration of 0 whose key matches no TTL rule. Such items
+	// never expire if it is 0.
+	DefaultTTL time.Duration
+
+	// TTLRules are the default expiration durations by key pattern, see
+	// WithTTLRules.
+	TTLRules []TTLRule
+
+	// GetDoesNotPromote makes Get keep the access order of the cache, see
+	// WithGetDoesNotPromote.
+	GetDoesNotPromote bool
+
+	// JanitorInterval is the interval of the janitor, see WithJanitor. The
+	// janitor is stopped if it is 0, and started if it was 0, so a cache
+	// whose janitor is started by Reconfigure must be closed. Changing it
+	// after Close starts the janitor again.
+	JanitorInterval time.Duration
+}
+
+// WithDefaultTTL sets the expiration duration of the items added with an
+// expiration duration of 0 whose key matches no rule set with WithTTLRules.
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.defaultTTL = ttl
+	}
+}
+
+// Config returns the current runtime settings of the cache. It can be
+// changed and passed to Reconfigure to change a single setting.
+func (c *Cache) Config() Config {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	rules := make([]TTLRule, len(c.ttlRules))
+	copy(rules, c.ttlRules)
+	cfg := Config{
+		Capacity:          c.cap,
+		DefaultTTL:        c.defaultTTL,
+		TTLRules:          rules,
+		GetDoesNotPromote: c.noPromote,
+	}
+	if c.janitor != nil {
+		cfg.JanitorInterval = c.janitor.interval
+	}
+	return cfg
+}
+
+// Reconfigure replaces the runtime settings of the cache with cfg without
+// recreating it, so a live service can be tuned. Settings apply to later
+// calls, e.g. a new DefaultTTL doesn't change the expiration of existing
+// items. It returns error and changes nothing if cfg is invalid.
+func (c *Cache) Reconfigure(cfg Config) (err error) {
+	defer recoverCorrupt(&err)
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	rules := make([]TTLRule, len(cfg.TTLRules))
+	copy(rules, cfg.TTLRules)
+
+	defer c.resizeChildren()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cfg.Capacity != c.cap {
+		c.resize(cfg.Capacity)
+	}
+	c.defaultTTL = cfg.DefaultTTL
+	c.ttlRules = rules
+	c.noPromote = cfg.GetDoesNotPromote
+	c.setJanitorInterval(cfg.JanitorInterval)
+	return nil
+}
+
+// validate returns error if the settings can't be applied.
+func (cfg Config) validate() error {
+	if errs := cfg.problems(); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// problems returns all reasons the settings can't be applied. It is shared by
+// Reconfigure and CacheBuilder, so both accept the same settings.
+func (cfg Config) problems() []error {
+	var errs []error
+	if cfg.Capacity == 0 {
+		errs = append(errs, errZeroCapacity)
+	}
+	if cfg.Capacity < 0 {
+		errs = append(errs, errNegCapacity)
+	}
+	if cfg.DefaultTTL < 0 {
+		errs = append(errs, fmt.Errorf("default ttl %v should not be negative", cfg.DefaultTTL))
+	}
+	if cfg.JanitorInterval < 0 {
+		errs = append(errs, fmt.Errorf("janitor interval %v should not be negative", cfg.JanitorInterval))
+	}
+	for _, rule := range cfg.TTLRules {
+		if _, err := path.Match(rule.Pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("ttl rule pattern %q: %w", rule.Pattern, err))
+		}
+		if rule.TTL < 0 {
+			errs = append(errs, fmt.Errorf("ttl rule %q has negative ttl %v", rule.Pattern, rule.TTL))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,173 @@
+package cache
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestCache_Reconfigure(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        Config
+		wantErr    bool
+		wantConfig Config
+		wantLength int
+	}{
+		{
+			name:       "applies valid configuration",
+			cfg:        Config{Capacity: 2, DefaultTTL: time.Hour, TTLRules: []TTLRule{{Pattern: "user:*", TTL: time.Minute}}, GetDoesNotPromote: true},
+			wantConfig: Config{Capacity: 2, DefaultTTL: time.Hour, TTLRules: []TTLRule{{Pattern: "user:*", TTL: time.Minute}}, GetDoesNotPromote: true},
+			wantLength: 2,
+		},
+		{
+			name:       "starts janitor",
+			cfg:        Config{Capacity: 3, JanitorInterval: time.Minute},
+			wantConfig: Config{Capacity: 3, TTLRules: []TTLRule{}, JanitorInterval: time.Minute},
+			wantLength: 3,
+		},
+		{
+			name:       "rejects negative janitor interval",
+			cfg:        Config{Capacity: 3, JanitorInterval: -time.Minute},
+			wantErr:    true,
+			wantConfig: Config{Capacity: 3, TTLRules: []TTLRule{}},
+			wantLength: 3,
+		},
+		{
+			name:       "rejects zero capacity",
+			cfg:        Config{Capacity: 0, DefaultTTL: time.Hour},
+			wantErr:    true,
+			wantConfig: Config{Capacity: 3, TTLRules: []TTLRule{}},
+			wantLength: 3,
+		},
+		{
+			name:       "rejects negative default ttl",
+			cfg:        Config{Capacity: 3, DefaultTTL: -time.Hour},
+			wantErr:    true,
+			wantConfig: Config{Capacity: 3, TTLRules: []TTLRule{}},
+			wantLength: 3,
+		},
+		{
+			name:       "rejects malformed ttl rule pattern",
+			cfg:        Config{Capacity: 3, TTLRules: []TTLRule{{Pattern: "["}}},
+			wantErr:    true,
+			wantConfig: Config{Capacity: 3, TTLRules: []TTLRule{}},
+			wantLength: 3,
+		},
+		{
+			name:       "rejects negative ttl rule",
+			cfg:        Config{Capacity: 3, TTLRules: []TTLRule{{Pattern: "user:*", TTL: -time.Minute}}},
+			wantErr:    true,
+			wantConfig: Config{Capacity: 3, TTLRules: []TTLRule{}},
+			wantLength: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := createCache(t, 3)
+			defer c.Close()
+			addItems(t, c, [][]any{{k, v}, {k + k, v + v}, {k + k + k, v + v + v}})
+			if err := c.Reconfigure(tt.cfg); (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error, got %v, want error %v", err, tt.wantErr)
+			}
+			if got := c.Config(); !reflect.DeepEqual(got, tt.wantConfig) {
+				t.Errorf("cache.Config() = %+v, want %+v", got, tt.wantConfig)
+			}
+			if c.Len() != tt.wantLength {
+				t.Errorf("unexpected length, got %v, want %v", c.Len(), tt.wantLength)
+			}
+		})
+	}
+}
+
+func TestCache_ReconfigureConcurrentGet(t *testing.T) {
+	c := createCache(t, 3)
+	addItems(t, c, [][]any{{k, v}, {k + k, v + v}})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			cfg := c.Config()
+			cfg.GetDoesNotPromote = i%2 == 0
+			if err := c.Reconfigure(cfg); err != nil {
+				t.Errorf("unexpected error, got %v", err)
+			}
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		if _, found := c.Get(k); !found {
+			t.Errorf("expected key %v to be found", k)
+		}
+		runtime.Gosched()
+	}
+	<-done
+}
+
+func TestCache_ReconfigureJanitor(t *testing.T) {
+	c, err := New(3, WithJanitor(time.Hour))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer c.Close()
+	cfg := c.Config()
+	cfg.JanitorInterval = 10 * time.Millisecond
+	if err := c.Reconfigure(cfg); err != nil {
+		t.Fatalf(err.Error())
+	}
+	addItemsWithExp(t, c, [][]any{{k, v, time.Millisecond}})
+	time.Sleep(50 * time.Millisecond)
+	if c.Len() != 0 {
+		t.Errorf("expected janitor with new interval to remove expired item, got length %v", c.Len())
+	}
+
+	cfg.JanitorInterval = 0
+	if err := c.Reconfigure(cfg); err != nil {
+		t.Fatalf(err.Error())
+	}
+	addItemsWithExp(t, c, [][]any{{k, v, time.Millisecond}})
+	time.Sleep(50 * time.Millisecond)
+	if c.Len() != 1 {
+		t.Errorf("expected stopped janitor to keep expired item, got length %v", c.Len())
+	}
+	if err := c.Healthy(); err != nil {
+		t.Errorf("unexpected error, got %v", err)
+	}
+}
+
+func TestWithDefaultTTL(t *testing.T) {
+	c, err := New(3, WithDefaultTTL(time.Hour), WithTTLRules([]TTLRule{{Pattern: "user:*", TTL: time.Minute}}))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	tests := []struct {
+		name string
+		key  any
+		exp  time.Duration
+		want time.Duration
+	}{
+		{
+			name: "uses default ttl when no rule matches",
+			key:  k,
+			want: time.Hour,
+		},
+		{
+			name: "prefers matching rule",
+			key:  "user:42",
+			want: time.Minute,
+		},
+		{
+			name: "keeps explicit expiration duration",
+			key:  k,
+			exp:  time.Second,
+			want: time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.ttlOf(tt.key, tt.exp); got != tt.want {
+				t.Errorf("cache.ttlOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"runtime"
+	"runtime/metrics"
+	"sync"
+)
+
+// heapMetric is the runtime metric of the bytes occupied by the heap objects.
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// emergency evicts a ratio of the items after the garbage collections which
+// find the heap too big.
+type emergency struct {
+	limit uint64
+	ratio float64
+
+	// gc is signalled by the sentinel after each garbage collection.
+	gc   chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// gcSentinel is garbage collected in every cycle, and its finalizer signals
+// the emergency eviction. It has a pointer, so it is not batched with other
+// small objects by the allocator, which would delay its finalization.
+type gcSentinel struct {
+	e *emergency
+}
+
+// WithEmergencyEviction evicts the given ratio of the items, the least
+// recently used ones first, after each garbage collection which finds at
+// least limit bytes of heap objects, to prevent running out of memory during
+// bursts. At least one item is evicted each time the cache is not empty.
+// Ratios out of (0, 1] and a zero limit are ignored. The eviction runs in a
+// goroutine until Close is called, so a cache created with it must be closed
+// when it is no longer used.
+func WithEmergencyEviction(limit uint64, ratio float64) Option {
+	return func(c *Cache) {
+		if limit == 0 || ratio <= 0 || ratio > 1 {
+			return
+		}
+		c.emergency = &emergency{limit: limit, ratio: ratio}
+	}
+}
+
+// startEmergency arms the sentinel and starts the goroutine of the emergency
+// eviction.
+func (c *Cache) startEmergency() {
+	e := c.emergency
+	e.gc = make(chan struct{}, 1)
+	e.done = make(chan struct{})
+	e.arm(&gcSentinel{e: e})
+	go c.labeled("emergency eviction", func(context.Context) {
+		sample := []metrics.Sample{{Name: heapMetric}}
+		for {
+			select {
+			case <-e.gc:
+				metrics.Read(sample)
+				if sample[0].Value.Kind() == metrics.KindUint64 && sample[0].Value.Uint64() >= e.limit {
+					c.guard("emergency eviction", c.shed)
+				}
+			case <-e.done:
+				return
+			}
+		}
+	})
+}
+
+// arm sets the finalizer of the sentinel, which signals the garbage
+// collection and arms the sentinel again until the eviction is stopped. The
+// finalizer doesn't block, since all finalizers run in a single goroutine.
+func (e *emergency) arm(s *gcSentinel) {
+	runtime.SetFinalizer(s, func(s *gcSentinel) {
+		select {
+		case <-e.done:
+			return
+		default:
+		}
+		select {
+		case e.gc <- struct{}{}:
+		default:
+		}
+		e.arm(s)
+	})
+}
+
+// stop stops the emergency eviction.
+func (e *emergency) stop() {
+	e.once.Do(func() { close(e.done) })
+}
+
+// shed evicts the ratio of the items set with WithEmergencyEviction.
+func (c *Cache) shed() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := int(float64(c.len)*c.emergency.ratio + 0.5)
+	if n == 0 {
+		n = 1
+	}
+	for i := 0; i < n; i++ {
+		if !c.evictOldest(EvictMemory) {
+			return
+		}
+	}
+}
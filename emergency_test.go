@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWithEmergencyEviction(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []Option
+		wantLength int
+	}{
+		{
+			name:       "evicts ratio of items when heap exceeds limit",
+			opts:       []Option{WithEmergencyEviction(1, 0.5)},
+			wantLength: 5,
+		},
+		{
+			name:       "keeps items when heap is under limit",
+			opts:       []Option{WithEmergencyEviction(1<<62, 0.5)},
+			wantLength: 10,
+		},
+		{
+			name:       "ignores invalid ratio",
+			opts:       []Option{WithEmergencyEviction(1, 1.5)},
+			wantLength: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(10, tt.opts...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			defer c.Close()
+			for i := 0; i < 10; i++ {
+				if err := c.Add(i, v, 0); err != nil {
+					t.Fatalf(err.Error())
+				}
+			}
+			runtime.GC()
+			deadline := time.Now().Add(time.Second)
+			for c.Len() != tt.wantLength && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			if c.Len() != tt.wantLength {
+				t.Fatalf("unexpected length, got %v, want %v", c.Len(), tt.wantLength)
+			}
+			if tt.wantLength < 10 {
+				if _, found := c.Peek(9); !found {
+					t.Errorf("expected most recently used key %v to be kept", 9)
+				}
+			}
+		})
+	}
+}
+
+func TestCache_CloseEmergencyEviction(t *testing.T) {
+	c, err := New(10, WithEmergencyEviction(1, 0.5))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	c.Close()
+	addItems(t, c, [][]any{{k, v}, {k + k, v}})
+	runtime.GC()
+	runtime.GC()
+	time.Sleep(50 * time.Millisecond)
+	if c.Len() != 2 {
+		t.Errorf("expected closed cache to keep items, got length %v", c.Len())
+	}
+}
@@ -1,12 +1,119 @@
 package cache
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrKeyExists is returned by Add when the key is already in the cache and
+// not expired. Add never replaces or duplicates such an item, use Replace or
+// UpdateVal to change its value.
+var ErrKeyExists = errors.New("key already exists")
+
+// ErrNotAdmitted is returned by Add when the admission function set with
+// WithAdmitFunc rejects the item. The item is not saved.
+var ErrNotAdmitted = errors.New("item is not admitted")
+
+// ErrCacheFull is returned by Add with NoEvictOthers when the cache, the quota
+// of the key's namespace or the size or cost limit is full. No item is
+// evicted.
+var ErrCacheFull = errors.New("cache is full")
+
+// ErrNoBudget is returned by Add when the capacity shared with the parent or
+// the children created by NewChild is exhausted and the cache has no item of
+// its own to evict.
+var ErrNoBudget = errors.New("shared capacity is exhausted")
+
+// ErrTooLarge is returned by Add, Replace and UpdateVal when the size or the
+// cost of the item is bigger than the limit set with WithMaxBytes or
+// WithMaxCost, or the item can't fit without evicting the item being
+// updated. The existing item is kept.
+var ErrTooLarge = errors.New("item is bigger than the size limit")
+
+// ErrUnknownSize is returned by Add, Replace and UpdateVal when the cache is
+// created with WithMaxBytes without a size function and the size of the value
+// is unknown, since it is neither a Sizer, a []byte nor a string, or when the
+// size function or the Sizer reports a negative size.
+var ErrUnknownSize = errors.New("size of value is unknown")
 
 var (
 	errEmptyCache   = errors.New("cache is empty")
 	errNegCapacity  = errors.New("capacity cannot be negative")
 	errZeroCapacity = errors.New("cache capacity should be more than zero")
-	errKeyExist     = errors.New("key already exists")
 	errKeyNotExist  = errors.New("key does not exist")
 	errNoKey        = errors.New("there is no such key")
+
+	errInvalidSchedule = errors.New("invalid schedule spec")
+	errInvalidShare    = errors.New("share should be more than zero and at most one")
+	errInvalidInterval = errors.New("interval should be more than zero")
+	errInvalidSnapshot = errors.New("invalid snapshot")
+	errSnapshotVersion = errors.New("unsupported snapshot version")
+	errCorruptRecord   = errors.New("corrupt snapshot record")
+	errDecrypt         = errors.New("data can't be decrypted")
+	errChecksum        = errors.New("checksum mismatch")
+	errNotBytes        = errors.New("value codecs require []byte values")
+	errLoadPanicked    = errors.New("loader panicked")
+	errNegativeCost    = errors.New("cost is negative")
+	errNotWeighted     = errors.New("cost is not limited with WithMaxCost")
+	errNoSnapshot      = errors.New("no snapshot found")
+
+	errQuotaNoNamespace = errors.New("namespace quotas require a namespace function")
+	errInconsistent     = errors.New("cache is inconsistent")
+	errJanitorStopped   = errors.New("janitor is stopped")
+	errJanitorPanicked  = errors.New("janitor panicked")
 )
+
+// ValidationError is returned when a value is rejected by the validator set
+// with WithValidator.
+type ValidationError struct {
+	// Key is the key of the rejected value.
+	Key interface{}
+
+	// Err is the error returned by the validator.
+	Err error
+}
+
+// Error returns the error message.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid value for key %v: %v", e.Key, e.Err)
+}
+
+// Unwrap returns the error returned by the validator.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// CorruptError is returned when an element of the list of the cache doesn't
+// hold an Item, which means the cache is corrupted, e.g. by a data race in
+// the caller. The methods which can't return error panic with it instead.
+type CorruptError struct {
+	// Value is the value held by the element.
+	Value interface{}
+}
+
+// Error returns the error message.
+func (e *CorruptError) Error() string {
+	return fmt.Sprintf("%v: unexpected element %T", errInconsistent, e.Value)
+}
+
+// Unwrap returns the error reported by Healthy for an inconsistent cache.
+func (e *CorruptError) Unwrap() error {
+	return errInconsistent
+}
+
+// ConfigError is returned by CacheBuilder.Build when the configuration is
+// invalid.
+type ConfigError struct {
+	// Errs are the problems of the configuration.
+	Errs []error
+}
+
+// Error returns the error message listing all problems.
+func (e *ConfigError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return "invalid cache configuration: " + strings.Join(msgs, "; ")
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// ndjsonEntry is a single line written by ExportNDJSON.
+type ndjsonEntry struct {
+	Key   interface{} `json:"key"`
+	Value interface{} `json:"value"`
+	TTL   float64     `json:"ttl"`
+	Hits  uint64      `json:"hits"`
+	Age   float64     `json:"age"`
+}
+
+// ExportNDJSON writes the items in cache to w as newline delimited JSON, one
+// object per item with "key", "value", "ttl", "hits" and "age" fields. ttl is
+// the remaining time to live in seconds, -1 for items that never expire, and
+// age is the time since the item is added in seconds. hits and age are 0
+// if the cache is created with WithCompactItems. Items are written from
+// the most recently used to the least recently used one, expired and
+// invalidated items are skipped. Keys and values must be encodable with
+// encoding/json. It works on a snapshot of the cache and does not change
+// frequency of the item access.
+func (c *Cache) ExportNDJSON(w io.Writer) (err error) {
+	defer recoverCorrupt(&err)
+	items := c.snapshot(false, func(item Item) bool { return !item.Expired() })
+
+	enc := json.NewEncoder(w)
+	now := time.Now().UnixNano()
+	for _, item := range items {
+		entry := ndjsonEntry{
+			Key:   item.Key,
+			Value: item.Val,
+			TTL:   -1,
+			Hits:  item.Hits,
+		}
+		if item.Created != 0 {
+			entry.Age = time.Duration(now - item.Created).Seconds()
+		}
+		if item.expiresAt() != 0 {
+			entry.TTL = item.RemainingTTL().Seconds()
+		}
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestCache_ExportNDJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		addPairs [][]any
+		getKeys  []any
+		want     []ndjsonEntry
+	}{
+		{
+			name:     "writes nothing for empty cache",
+			addPairs: [][]any{},
+			want:     nil,
+		},
+		{
+			name:     "writes one line per unexpired item in access order",
+			addPairs: [][]any{{k, v, time.Duration(0)}, {k + k, v + v, time.Hour}, {k + k + k, v + v + v, -1 * time.Hour}},
+			getKeys:  []any{k, k},
+			want: []ndjsonEntry{
+				{Key: k, Value: v, TTL: -1, Hits: 2},
+				{Key: k + k, Value: v + v, TTL: time.Hour.Seconds()},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := createCache(t, 3)
+			addItemsWithExp(t, c, tt.addPairs)
+			for _, key := range tt.getKeys {
+				c.Get(key)
+			}
+			var buf bytes.Buffer
+			if err := c.ExportNDJSON(&buf); err != nil {
+				t.Fatalf("unexpected error, got %v", err)
+			}
+
+			var got []ndjsonEntry
+			sc := bufio.NewScanner(&buf)
+			for sc.Scan() {
+				var e ndjsonEntry
+				if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+					t.Fatalf("unexpected error decoding %q, got %v", sc.Text(), err)
+				}
+				got = append(got, e)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("unexpected line count, got %v, want %v", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got[i].Key != want.Key || got[i].Value != want.Value || got[i].Hits != want.Hits {
+					t.Errorf("unexpected entry, got %+v, want %+v", got[i], want)
+				}
+				if want.TTL == -1 && got[i].TTL != -1 {
+					t.Errorf("unexpected ttl, got %v, want %v", got[i].TTL, -1)
+				}
+				if want.TTL > 0 && (got[i].TTL <= want.TTL-60 || got[i].TTL > want.TTL) {
+					t.Errorf("unexpected ttl, got %v, want about %v", got[i].TTL, want.TTL)
+				}
+				if got[i].Age < 0 {
+					t.Errorf("unexpected negative age %v", got[i].Age)
+				}
+			}
+		})
+	}
+}
+
+func TestCache_ExportNDJSONError(t *testing.T) {
+	c := createCache(t, 1)
+	addItems(t, c, [][]any{{k, func() {}}})
+	if err := c.ExportNDJSON(&bytes.Buffer{}); err == nil {
+		t.Errorf("expected error for value that can't be encoded")
+	}
+}
@@ -0,0 +1,92 @@
+package cache
+
+import "container/list"
+
+// ghost is a key whose item is removed, with the cause of the removal.
+type ghost struct {
+	key   interface{}
+	cause missCause
+}
+
+// ghostList keeps the keys of the latest removed items, the most recently
+// removed one at the front, up to size keys. byKey indexes its elements by
+// key. It is used by the capacity tuner, which needs the position of a key,
+// and by the miss classification, which needs the cause of its removal.
+type ghostList struct {
+	size  int
+	lst   *list.List
+	byKey map[interface{}]*list.Element
+}
+
+// newGhostList returns an empty ghost list keeping up to size keys.
+func newGhostList(size int) *ghostList {
+	return &ghostList{
+		size:  size,
+		lst:   list.New(),
+		byKey: make(map[interface{}]*list.Element),
+	}
+}
+
+// push records the removal of the key, forgetting the oldest removal if the
+// list is full.
+func (g *ghostList) push(key interface{}, cause missCause) {
+	if g == nil {
+		return
+	}
+	if e, ok := g.byKey[key]; ok {
+		e.Value = ghost{key: key, cause: cause}
+		g.lst.MoveToFront(e)
+		return
+	}
+	g.byKey[key] = g.lst.PushFront(ghost{key: key, cause: cause})
+	if g.lst.Len() > g.size {
+		g.forget(g.lst.Back())
+	}
+}
+
+// cause returns the cause of the removal of the key, and false if its
+// removal is not recorded.
+func (g *ghostList) cause(key interface{}) (missCause, bool) {
+	if g == nil {
+		return 0, false
+	}
+	e, ok := g.byKey[key]
+	if !ok {
+		return 0, false
+	}
+	return e.Value.(ghost).cause, true
+}
+
+// take forgets the key and returns its position in the list, 0 being the
+// most recently removed key. It returns false if the key is not in the list.
+func (g *ghostList) take(key interface{}) (int, bool) {
+	if g == nil {
+		return 0, false
+	}
+	e, ok := g.byKey[key]
+	if !ok {
+		return 0, false
+	}
+	pos := 0
+	for f := g.lst.Front(); f != e; f = f.Next() {
+		pos++
+	}
+	g.forget(e)
+	return pos, true
+}
+
+// drop forgets the key, e.g. when it is added again.
+func (g *ghostList) drop(key interface{}) {
+	if g == nil {
+		return
+	}
+	if e, ok := g.byKey[key]; ok {
+		g.forget(e)
+	}
+}
+
+// forget removes the element from the list.
+func (g *ghostList) forget(e *list.Element) {
+	delete(g.byKey, e.Value.(ghost).key)
+	g.lst.Remove(e)
+}
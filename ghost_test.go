@@ -0,0 +1,34 @@
+package cache
+
+import "testing"
+
+func TestGhostList(t *testing.T) {
+	g := newGhostList(2)
+	g.push("a", missExpired)
+	g.push("b", missEvicted)
+	g.push("a", missInvalidated)
+	g.push("c", missEvicted)
+
+	if _, ok := g.cause("b"); ok {
+		t.Errorf("expected oldest removal to be forgotten")
+	}
+	if cause, ok := g.cause("a"); !ok || cause != missInvalidated {
+		t.Errorf("unexpected cause, got %v, %v, want %v", cause, ok, missInvalidated)
+	}
+	if pos, ok := g.take("a"); !ok || pos != 1 {
+		t.Errorf("unexpected position, got %v, %v, want 1", pos, ok)
+	}
+	if _, ok := g.take("a"); ok {
+		t.Errorf("expected taken key to be forgotten")
+	}
+	g.drop("c")
+	if g.lst.Len() != 0 || len(g.byKey) != 0 {
+		t.Errorf("expected empty list, got %v keys", len(g.byKey))
+	}
+
+	var nilList *ghostList
+	nilList.push("a", missExpired)
+	if _, ok := nilList.cause("a"); ok {
+		t.Errorf("expected nil list to record nothing")
+	}
+}
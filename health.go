@@ -0,0 +1,209 @@
+package cache
+
+import (
+	"container/list"
+	"fmt"
+)
+
+// Healthy verifies the internal invariants of the cache, e.g. that the length
+// agrees with the stored items and no key is stored twice. It returns nil if
+// the cache is consistent, so it can be wired into readiness probes. It
+// works on a snapshot of the cache and does not change frequency of the item
+// access, but it walks all items. If the cache is created with WithJanitor,
+// it also returns error if the janitor is stopped, e.g. by Close, or one of
+// its sweeps panicked.
+func (c *Cache) Healthy() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if err := c.checkInvariants(); err != nil {
+		return err
+	}
+	if c.janitor != nil {
+		return c.janitor.err()
+	}
+	return nil
+}
+
+// checkInvariants returns error describing the first broken invariant of the
+// cache.
+func (c *Cache) checkInvariants() error {
+	if c.len < 0 {
+		return fmt.Errorf("%w: negative length %d", errInconsistent, c.len)
+	}
+	if c.len != c.lst.Len() {
+		return fmt.Errorf("%w: length %d does not match %d stored items", errInconsistent, c.len, c.lst.Len())
+	}
+	if c.len > c.cap {
+		return fmt.Errorf("%w: length %d exceeds capacity %d", errInconsistent, c.len, c.cap)
+	}
+
+	// Walking the list backwards must visit the same elements as walking it
+	// forwards.
+	var n int
+	for e := c.lst.Back(); e != nil; e = e.Prev() {
+		if n++; n > c.len {
+			break
+		}
+	}
+	if n != c.len {
+		return fmt.Errorf("%w: list order is broken", errInconsistent)
+	}
+
+	keys := make(map[interface{}]struct{}, c.len)
+	nsLen := make(map[string]int)
+	protected := 0
+	var cost int64
+	for e := c.lst.Front(); e != nil; e = e.Next() {
+		item, err := itemOf(e)
+		if err != nil {
+			return err
+		}
+		if item.protected {
+			protected++
+		}
+		cost += item.Cost
+		if _, dup := keys[item.Key]; dup {
+			return fmt.Errorf("%w: key %v is stored twice", errInconsistent, item.Key)
+		}
+		keys[item.Key] = struct{}{}
+		nsLen[c.namespace(item.Key)]++
+	}
+	if cost != c.cost {
+		return fmt.Errorf("%w: total size %d does not match %d of the stored items", errInconsistent, c.cost, cost)
+	}
+	if c.maxCost > 0 && c.cost > c.maxCost {
+		return fmt.Errorf("%w: total size %d exceeds limit %d", errInconsistent, c.cost, c.maxCost)
+	}
+	if protected != c.protectedLen {
+		return fmt.Errorf("%w: %d protected items are counted, %d are stored", errInconsistent, c.protectedLen, protected)
+	}
+	if len(nsLen) != len(c.nsLen) {
+		return fmt.Errorf("%w: %d namespaces are counted, %d are stored", errInconsistent, len(c.nsLen), len(nsLen))
+	}
+	for ns, n := range nsLen {
+		if c.nsLen[ns] != n {
+			return fmt.Errorf("%w: namespace %q has length %d, %d items are stored", errInconsistent, ns, c.nsLen[ns], n)
+		}
+	}
+	return nil
+}
+
+// AuditReport compares the length counters of the cache with the items
+// actually stored, see Audit.
+type AuditReport struct {
+	// Len is the length reported by Len.
+	Len int
+
+	// Cap is the capacity reported by Cap.
+	Cap int
+
+	// Stored is the number of items stored in the list.
+	Stored int
+
+	// Keys is the number of distinct keys stored. It is less than Stored if
+	// a key is stored more than once.
+	Keys int
+
+	// NamespaceLen is the sum of the lengths counted for each namespace.
+	NamespaceLen int
+
+	// Discrepancies describes each counter which doesn't agree with the
+	// stored items. It is empty if the accounting is consistent.
+	Discrepancies []string
+}
+
+// Audit compares the length counters of the cache with the stored items.
+// Unlike Healthy, which stops at the first broken invariant, it reports the
+// counters along with every discrepancy, so accounting drift can be logged
+// or exported. Like Healthy, it walks all items.
+func (c *Cache) Audit() AuditReport {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	r := AuditReport{Len: c.len, Cap: c.cap, Stored: c.lst.Len()}
+	keys := make(map[interface{}]struct{}, c.len)
+	nsLen := make(map[string]int)
+	for e := c.lst.Front(); e != nil; e = e.Next() {
+		key := mustItem(e).Key
+		keys[key] = struct{}{}
+		nsLen[c.namespace(key)]++
+	}
+	r.Keys = len(keys)
+	for _, n := range c.nsLen {
+		r.NamespaceLen += n
+	}
+
+	if r.Len != r.Stored {
+		r.Discrepancies = append(r.Discrepancies, fmt.Sprintf("length %d does not match %d stored items", r.Len, r.Stored))
+	}
+	if r.Len > r.Cap {
+		r.Discrepancies = append(r.Discrepancies, fmt.Sprintf("length %d exceeds capacity %d", r.Len, r.Cap))
+	}
+	if r.Keys != r.Stored {
+		r.Discrepancies = append(r.Discrepancies, fmt.Sprintf("%d items are stored for %d keys", r.Stored, r.Keys))
+	}
+	if r.NamespaceLen != r.Stored {
+		r.Discrepancies = append(r.Discrepancies, fmt.Sprintf("namespace lengths sum to %d, %d items are stored", r.NamespaceLen, r.Stored))
+	}
+	for ns, n := range c.nsLen {
+		if nsLen[ns] != n {
+			r.Discrepancies = append(r.Discrepancies, fmt.Sprintf("namespace %q has length %d, %d items are stored", ns, n, nsLen[ns]))
+		}
+	}
+	for ns, n := range nsLen {
+		if _, ok := c.nsLen[ns]; !ok {
+			r.Discrepancies = append(r.Discrepancies, fmt.Sprintf("namespace %q has no length, %d items are stored", ns, n))
+		}
+	}
+	return r
+}
+
+// Dedupe removes the items whose key is stored more than once, keeping the
+// most recently used one, which is the one Get returns. The length of the
+// cache and its namespaces are updated accordingly. Add never stores a key
+// twice, so it is meant to recover a cache which Healthy reports as
+// inconsistent. It returns the number of removed items.
+func (c *Cache) Dedupe() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	seen := make(map[interface{}]struct{}, c.len)
+	removed := 0
+	var next *list.Element
+	for e := c.lst.Front(); e != nil; e = next {
+		next = e.Next()
+		key := mustItem(e).Key
+		if _, dup := seen[key]; dup {
+			c.remove(e)
+			removed++
+			continue
+		}
+		seen[key] = struct{}{}
+	}
+	return removed
+}
+
+// WithInvariantChecks makes the cache verify its internal invariants after
+// every operation that changes it, and panic if they are broken. Checks walk
+// all items while holding the lock, so it is meant for tests and staging
+// environments, not production.
+func WithInvariantChecks() Option {
+	return func(c *Cache) {
+		c.checkAll = true
+	}
+}
+
+// checkedLocker is a locker that verifies the invariants of the cache before
+// releasing the write lock.
+type checkedLocker struct {
+	locker
+	c *Cache
+}
+
+// Unlock panics if the invariants of the cache are broken, otherwise it
+// releases the lock.
+func (l *checkedLocker) Unlock() {
+	if err := l.c.checkInvariants(); err != nil {
+		l.locker.Unlock()
+		panic(err)
+	}
+	l.locker.Unlock()
+}
@@ -0,0 +1,251 @@
+package cache
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCache_Healthy(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(c *Cache)
+		wantErr error
+	}{
+		{
+			name:    "reports consistent cache as healthy",
+			corrupt: func(c *Cache) {},
+			wantErr: nil,
+		},
+		{
+			name:    "detects length drift",
+			corrupt: func(c *Cache) { c.len++ },
+			wantErr: errInconsistent,
+		},
+		{
+			name:    "detects duplicate keys",
+			corrupt: func(c *Cache) { c.insert(Item{Key: k, Val: v}) },
+			wantErr: errInconsistent,
+		},
+		{
+			name:    "detects namespace length drift",
+			corrupt: func(c *Cache) { c.nsLen["other"] = 1 },
+			wantErr: errInconsistent,
+		},
+		{
+			name:    "detects length over capacity",
+			corrupt: func(c *Cache) { c.cap = 1 },
+			wantErr: errInconsistent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := createCache(t, 3)
+			addItems(t, c, [][]any{{k, v}, {k + k, v + v}})
+			c.Get(k)
+			c.Remove(k + k)
+			addItems(t, c, [][]any{{k + k + k, v + v + v}})
+			tt.corrupt(c)
+			if err := c.Healthy(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error, got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCache_HealthyJanitor(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		op      func(c *Cache)
+		wantErr error
+	}{
+		{
+			name:    "reports running janitor as healthy",
+			opts:    []Option{WithJanitor(10 * time.Millisecond)},
+			op:      func(c *Cache) {},
+			wantErr: nil,
+		},
+		{
+			name:    "reports closed janitor",
+			opts:    []Option{WithJanitor(10 * time.Millisecond)},
+			op:      func(c *Cache) { c.Close() },
+			wantErr: errJanitorStopped,
+		},
+		{
+			name: "reports panicked sweep",
+			opts: []Option{
+				WithJanitor(10 * time.Millisecond),
+				WithOnEvict(func(key, val any) { panic("boom") }),
+				WithPanicHandler(func(err *PanicError) {}),
+			},
+			op: func(c *Cache) {
+				addItemsWithExp(t, c, [][]any{{k, v, time.Millisecond}})
+			},
+			wantErr: errJanitorPanicked,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(3, tt.opts...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			defer c.Close()
+			tt.op(c)
+			time.Sleep(50 * time.Millisecond)
+			if err := c.Healthy(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error, got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCache_Audit(t *testing.T) {
+	tests := []struct {
+		name              string
+		corrupt           func(c *Cache)
+		wantReport        AuditReport
+		wantDiscrepancies int
+	}{
+		{
+			name:       "reports consistent accounting",
+			corrupt:    func(c *Cache) {},
+			wantReport: AuditReport{Len: 2, Cap: 3, Stored: 2, Keys: 2, NamespaceLen: 2},
+		},
+		{
+			name:              "reports length drift",
+			corrupt:           func(c *Cache) { c.len++ },
+			wantReport:        AuditReport{Len: 3, Cap: 3, Stored: 2, Keys: 2, NamespaceLen: 2},
+			wantDiscrepancies: 1,
+		},
+		{
+			name:              "reports length over capacity",
+			corrupt:           func(c *Cache) { c.len += 2 },
+			wantReport:        AuditReport{Len: 4, Cap: 3, Stored: 2, Keys: 2, NamespaceLen: 2},
+			wantDiscrepancies: 2,
+		},
+		{
+			name:              "reports duplicate keys",
+			corrupt:           func(c *Cache) { c.insert(Item{Key: k, Val: v}) },
+			wantReport:        AuditReport{Len: 3, Cap: 3, Stored: 3, Keys: 2, NamespaceLen: 3},
+			wantDiscrepancies: 1,
+		},
+		{
+			name:              "reports namespace length drift",
+			corrupt:           func(c *Cache) { c.nsLen["other"] = 1 },
+			wantReport:        AuditReport{Len: 2, Cap: 3, Stored: 2, Keys: 2, NamespaceLen: 3},
+			wantDiscrepancies: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := createCache(t, 3)
+			addItems(t, c, [][]any{{k, v}, {k + k, v + v}})
+			tt.corrupt(c)
+			report := c.Audit()
+			if len(report.Discrepancies) != tt.wantDiscrepancies {
+				t.Errorf("unexpected discrepancies, got %v, want %v", report.Discrepancies, tt.wantDiscrepancies)
+			}
+			report.Discrepancies = nil
+			if !reflect.DeepEqual(report, tt.wantReport) {
+				t.Errorf("unexpected report, got %+v, want %+v", report, tt.wantReport)
+			}
+		})
+	}
+}
+
+func TestCache_Dedupe(t *testing.T) {
+	tests := []struct {
+		name        string
+		corrupt     func(c *Cache)
+		wantRemoved int
+		wantVal     any
+	}{
+		{
+			name:        "removes nothing from consistent cache",
+			corrupt:     func(c *Cache) {},
+			wantRemoved: 0,
+			wantVal:     v,
+		},
+		{
+			name:        "keeps most recently used duplicate",
+			corrupt:     func(c *Cache) { c.insert(Item{Key: k, Val: v + v}) },
+			wantRemoved: 1,
+			wantVal:     v + v,
+		},
+		{
+			name: "removes every duplicate",
+			corrupt: func(c *Cache) {
+				c.insert(Item{Key: k, Val: v + v})
+				c.insert(Item{Key: k + k, Val: v})
+				c.insert(Item{Key: k, Val: v + v + v})
+			},
+			wantRemoved: 3,
+			wantVal:     v + v + v,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := createCache(t, 5)
+			addItems(t, c, [][]any{{k, v}, {k + k, v + v}})
+			tt.corrupt(c)
+			if removed := c.Dedupe(); removed != tt.wantRemoved {
+				t.Errorf("unexpected removed count, got %v, want %v", removed, tt.wantRemoved)
+			}
+			if err := c.Healthy(); err != nil {
+				t.Errorf("unexpected error after dedupe, got %v", err)
+			}
+			if val, _ := c.Peek(k); val != tt.wantVal {
+				t.Errorf("unexpected value, got %v, want %v", val, tt.wantVal)
+			}
+		})
+	}
+}
+
+func TestWithInvariantChecks(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		corrupt   func(c *Cache)
+		wantPanic bool
+	}{
+		{
+			name:      "does not panic for consistent cache",
+			opts:      []Option{WithInvariantChecks()},
+			corrupt:   func(c *Cache) {},
+			wantPanic: false,
+		},
+		{
+			name:      "panics after change breaking invariants",
+			opts:      []Option{WithInvariantChecks(), WithLockMode(LockFair)},
+			corrupt:   func(c *Cache) { c.len++ },
+			wantPanic: true,
+		},
+		{
+			name:      "does not check without option",
+			opts:      nil,
+			corrupt:   func(c *Cache) { c.len++ },
+			wantPanic: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(3, tt.opts...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			addItems(t, c, [][]any{{k, v}, {k + k, v + v}})
+			tt.corrupt(c)
+			defer func() {
+				if r := recover(); (r != nil) != tt.wantPanic {
+					t.Errorf("unexpected panic, got %v, want panic %v", r, tt.wantPanic)
+				}
+				// The lock must be released even if the check panics.
+				c.Len()
+			}()
+			c.Get(k)
+		})
+	}
+}
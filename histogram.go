@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// DefaultRecencyBounds are the bucket bounds used by RecencyHistogram when no
+// bounds are given.
+var DefaultRecencyBounds = []time.Duration{
+	time.Second,
+	10 * time.Second,
+	time.Minute,
+	10 * time.Minute,
+}
+
+// Histogram is a cumulative histogram of durations.
+type Histogram struct {
+	// Bounds are the upper bounds of the buckets in increasing order.
+	Bounds []time.Duration
+
+	// Counts holds the number of observations less than or equal to the
+	// bound with the same index.
+	Counts []uint64
+
+	// Count is the total number of observations, including the ones bigger
+	// than the last bound.
+	Count uint64
+
+	// Sum is the sum of all observations.
+	Sum time.Duration
+}
+
+// newHistogram creates an empty histogram with the given bounds.
+func newHistogram(bounds []time.Duration) Histogram {
+	b := make([]time.Duration, len(bounds))
+	copy(b, bounds)
+	return Histogram{
+		Bounds: b,
+		Counts: make([]uint64, len(bounds)),
+	}
+}
+
+// observe adds d to the histogram.
+func (h *Histogram) observe(d time.Duration) {
+	for i, b := range h.Bounds {
+		if d <= b {
+			h.Counts[i]++
+		}
+	}
+	h.Count++
+	h.Sum += d
+}
+
+// WriteOpenMetrics writes the histogram in OpenMetrics text format with the
+// given metric name. Durations are written in seconds, so the name should
+// end with "_seconds". The "# EOF" line is not written, so the output can be
+// combined with other metrics.
+func (h Histogram) WriteOpenMetrics(w io.Writer, name string) error {
+	if _, err := fmt.Fprintf(w, "# TYPE %s histogram\n", name); err != nil {
+		return err
+	}
+	for i, b := range h.Bounds {
+		le := strconv.FormatFloat(b.Seconds(), 'g', -1, 64)
+		if _, err := fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", name, le, h.Counts[i]); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, h.Count); err != nil {
+		return err
+	}
+	sum := strconv.FormatFloat(h.Sum.Seconds(), 'g', -1, 64)
+	if _, err := fmt.Fprintf(w, "%s_sum %s\n%s_count %d\n", name, sum, name, h.Count); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DefaultLifetimeBounds are the bucket bounds used by WithLifetimeHistograms
+// when no bounds are given.
+var DefaultLifetimeBounds = []time.Duration{
+	time.Minute,
+	10 * time.Minute,
+	time.Hour,
+	6 * time.Hour,
+	24 * time.Hour,
+}
+
+// lifetimes records the expiration durations assigned to the items and how
+// long the items stay in the cache.
+type lifetimes struct {
+	ttl      Histogram
+	lifetime Histogram
+}
+
+// WithLifetimeHistograms records the distribution of the expiration durations
+// assigned to the items when they are added, and of the time the items stay
+// in the cache until they are evicted or removed since they are expired. The
+// distributions are returned by TTLHistogram and LifetimeHistogram, and show
+// whether the expiration durations match how long the items are useful.
+// Items added without expiration are not counted by TTLHistogram. Bounds must
+// be in increasing order. DefaultLifetimeBounds is used if no bounds are
+// given.
+func WithLifetimeHistograms(bounds ...time.Duration) Option {
+	return func(c *Cache) {
+		if len(bounds) == 0 {
+			bounds = DefaultLifetimeBounds
+		}
+		c.lifetimes = &lifetimes{
+			ttl:      newHistogram(bounds),
+			lifetime: newHistogram(bounds),
+		}
+	}
+}
+
+// assigned records the expiration duration of an added item.
+func (l *lifetimes) assigned(exp time.Duration) {
+	if l == nil || exp == 0 {
+		return
+	}
+	l.ttl.observe(exp)
+}
+
+// removed records the lifetime of a removed item if it is evicted or expired.
+func (l *lifetimes) removed(item Item, evicted bool) {
+	if l == nil {
+		return
+	}
+	now := time.Now().UnixNano()
+	if evicted || item.expiredAt(now) {
+		l.lifetime.observe(time.Duration(now - item.Created))
+	}
+}
+
+// TTLHistogram returns the distribution of the expiration durations assigned
+// to the items, see WithLifetimeHistograms. It is empty if the recording is
+// disabled.
+func (c *Cache) TTLHistogram() Histogram {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.lifetimes == nil {
+		return Histogram{}
+	}
+	return c.lifetimes.ttl.clone()
+}
+
+// LifetimeHistogram returns the distribution of the time the items stayed in
+// the cache until they are evicted or expired, see WithLifetimeHistograms. It
+// is empty if the recording is disabled.
+func (c *Cache) LifetimeHistogram() Histogram {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.lifetimes == nil {
+		return Histogram{}
+	}
+	return c.lifetimes.lifetime.clone()
+}
+
+// clone returns a copy of the histogram which doesn't share its buckets.
+func (h Histogram) clone() Histogram {
+	h.Bounds = append([]time.Duration(nil), h.Bounds...)
+	h.Counts = append([]uint64(nil), h.Counts...)
+	return h
+}
+
+// RecencyHistogram returns the distribution of the time since each item is
+// last accessed. It shows how fast the working set of the cache decays, e.g.
+// how many items are accessed within the last second, 10 seconds, minute.
+// Bounds must be in increasing order. DefaultRecencyBounds is used if no
+// bounds are given. If the cache is created with WithCompactItems, only the
+// items with an idle timeout are counted, since the access time of the others
+// is not recorded.
+func (c *Cache) RecencyHistogram(bounds ...time.Duration) Histogram {
+	if len(bounds) == 0 {
+		bounds = DefaultRecencyBounds
+	}
+	h := newHistogram(bounds)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	now := time.Now().UnixNano()
+	for e := c.lst.Front(); e != nil; e = e.Next() {
+		if item := mustItem(e); !c.stale(item) && item.Accessed != 0 {
+			h.observe(time.Duration(now - item.Accessed))
+		}
+	}
+	return h
+}
@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCache_RecencyHistogram(t *testing.T) {
+	tests := []struct {
+		name       string
+		ages       []time.Duration
+		bounds     []time.Duration
+		wantCounts []uint64
+		wantCount  uint64
+	}{
+		{
+			name:       "returns empty histogram for empty cache",
+			ages:       []time.Duration{},
+			bounds:     nil,
+			wantCounts: []uint64{0, 0, 0, 0},
+			wantCount:  0,
+		},
+		{
+			name:       "counts items cumulatively by last access",
+			ages:       []time.Duration{0, 5 * time.Second, 30 * time.Second, time.Hour},
+			bounds:     nil,
+			wantCounts: []uint64{1, 2, 3, 3},
+			wantCount:  4,
+		},
+		{
+			name:       "uses the given bounds",
+			ages:       []time.Duration{0, 2 * time.Minute},
+			bounds:     []time.Duration{time.Minute, time.Hour},
+			wantCounts: []uint64{1, 2},
+			wantCount:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := createCache(t, 5)
+			now := time.Now()
+			for i, age := range tt.ages {
+				if err := c.Add(i, v, 0); err != nil {
+					t.Fatalf(err.Error())
+				}
+				item := mustItem(c.lst.Front())
+				item.Accessed = now.Add(-age).UnixNano()
+				setItem(c.lst.Front(), item)
+			}
+			got := c.RecencyHistogram(tt.bounds...)
+			if !reflect.DeepEqual(got.Counts, tt.wantCounts) {
+				t.Errorf("unexpected counts, got %v, want %v", got.Counts, tt.wantCounts)
+			}
+			if got.Count != tt.wantCount {
+				t.Errorf("unexpected count, got %v, want %v", got.Count, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestHistogram_WriteOpenMetrics(t *testing.T) {
+	h := newHistogram([]time.Duration{time.Second, time.Minute})
+	h.observe(500 * time.Millisecond)
+	h.observe(30 * time.Second)
+	h.observe(time.Hour)
+
+	var buf bytes.Buffer
+	if err := h.WriteOpenMetrics(&buf, "cache_access_age_seconds"); err != nil {
+		t.Fatalf("unexpected error, got %v", err)
+	}
+	want := `# TYPE cache_access_age_seconds histogram
+cache_access_age_seconds_bucket{le="1"} 1
+cache_access_age_seconds_bucket{le="60"} 2
+cache_access_age_seconds_bucket{le="+Inf"} 3
+cache_access_age_seconds_sum 3630.5
+cache_access_age_seconds_count 3
+`
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output, got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWithLifetimeHistograms(t *testing.T) {
+	bounds := []time.Duration{time.Millisecond, time.Hour}
+	tests := []struct {
+		name             string
+		opts             []Option
+		op               func(c *Cache)
+		wantTTLCounts    []uint64
+		wantTTLCount     uint64
+		wantLifetimes    []uint64
+		wantLifetimeSize uint64
+	}{
+		{
+			name: "records assigned expiration durations",
+			opts: []Option{WithLifetimeHistograms(bounds...)},
+			op: func(c *Cache) {
+				c.Add(k, v, time.Minute)
+				c.Add(k+k, v, 2*time.Hour)
+				c.Add(k+k+k, v, 0)
+			},
+			wantTTLCounts: []uint64{0, 1},
+			wantTTLCount:  2,
+			wantLifetimes: []uint64{0, 0},
+		},
+		{
+			name: "records lifetimes of evicted and expired items",
+			opts: []Option{WithLifetimeHistograms(bounds...)},
+			op: func(c *Cache) {
+				c.Add(k, v, time.Millisecond)
+				c.Add(k+k, v, 0)
+				time.Sleep(5 * time.Millisecond)
+				c.ClearExpiredData()
+				c.Resize(1)
+				c.Add(k+k+k, v, 0)
+				c.Remove(k + k + k)
+			},
+			wantTTLCounts:    []uint64{1, 1},
+			wantTTLCount:     1,
+			wantLifetimes:    []uint64{0, 2},
+			wantLifetimeSize: 2,
+		},
+		{
+			name: "records nothing without option",
+			op: func(c *Cache) {
+				c.Add(k, v, time.Minute)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(3, tt.opts...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			tt.op(c)
+			ttl := c.TTLHistogram()
+			if !reflect.DeepEqual(ttl.Counts, tt.wantTTLCounts) || ttl.Count != tt.wantTTLCount {
+				t.Errorf("unexpected TTL histogram, got %v (%v), want %v (%v)", ttl.Counts, ttl.Count, tt.wantTTLCounts, tt.wantTTLCount)
+			}
+			lifetime := c.LifetimeHistogram()
+			if !reflect.DeepEqual(lifetime.Counts, tt.wantLifetimes) || lifetime.Count != tt.wantLifetimeSize {
+				t.Errorf("unexpected lifetime histogram, got %v (%v), want %v (%v)", lifetime.Counts, lifetime.Count, tt.wantLifetimes, tt.wantLifetimeSize)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// hotKeys counts the accesses of keys in fixed windows to detect the keys
+// whose access rate exceeds a threshold.
+type hotKeys struct {
+	mu        sync.Mutex
+	threshold uint64
+	window    int64
+	fn        func(key interface{})
+
+	// start is the start of the current window in Unix nanoseconds.
+	start  int64
+	counts map[interface{}]uint64
+
+	// hot and prev keep the keys that became hot in the current and the
+	// previous window.
+	hot  map[interface{}]struct{}
+	prev map[interface{}]struct{}
+
+	// pending keeps the keys that became hot but are not passed to fn yet.
+	pending []interface{}
+}
+
+// WithHotKeys enables the detection of hot keys, the keys retrieved with Get
+// at least threshold times within the given window. Windows are fixed, they
+// are not sliding. fn is called once per window for each key that becomes
+// hot, so it can be reported to metrics or replicated closer to the readers.
+// fn may be nil if the hot keys are only polled with HotKeys. fn is called
+// without holding the lock of the cache, after Get releases it.
+func WithHotKeys(threshold int, window time.Duration, fn func(key interface{})) Option {
+	return func(c *Cache) {
+		if threshold < 1 {
+			threshold = 1
+		}
+		c.hot = &hotKeys{
+			threshold: uint64(threshold),
+			window:    int64(window),
+			fn:        fn,
+			counts:    make(map[interface{}]uint64),
+			hot:       make(map[interface{}]struct{}),
+		}
+	}
+}
+
+// HotKeys returns the keys that became hot in the current or the previous
+// window, in no particular order. It returns nil if the cache is not created
+// with WithHotKeys.
+func (c *Cache) HotKeys() []interface{} {
+	return c.hot.keys(time.Now().UnixNano())
+}
+
+// observe counts an access to the key. A nil hotKeys ignores all accesses.
+func (h *hotKeys) observe(key interface{}, now int64) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.roll(now)
+	h.counts[key]++
+	if h.counts[key] == h.threshold {
+		h.hot[key] = struct{}{}
+		if h.fn != nil {
+			h.pending = append(h.pending, key)
+		}
+	}
+}
+
+// roll starts a new window if the current one has ended. The hot keys of the
+// current window are kept as the previous ones only if the new window follows
+// it directly.
+func (h *hotKeys) roll(now int64) {
+	if now-h.start < h.window {
+		return
+	}
+	h.prev = nil
+	if now-h.start < 2*h.window {
+		h.prev = h.hot
+	}
+	h.start = now
+	h.counts = make(map[interface{}]uint64)
+	h.hot = make(map[interface{}]struct{})
+}
+
+// keys returns the hot keys of the current and the previous window.
+func (h *hotKeys) keys(now int64) []interface{} {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.roll(now)
+	keys := make([]interface{}, 0, len(h.hot)+len(h.prev))
+	for key := range h.hot {
+		keys = append(keys, key)
+	}
+	for key := range h.prev {
+		if _, ok := h.hot[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// notify passes the keys that became hot to the callback. It must be called
+// without holding the lock of the cache.
+func (h *hotKeys) notify() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	pending := h.pending
+	h.pending = nil
+	h.mu.Unlock()
+	for _, key := range pending {
+		h.fn(key)
+	}
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestWithHotKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		getKeys   []any
+		wantHot   []any
+	}{
+		{
+			name:      "reports keys reaching threshold once",
+			threshold: 2,
+			getKeys:   []any{k, k + k, k, k, k},
+			wantHot:   []any{k},
+		},
+		{
+			name:      "does not report keys below threshold",
+			threshold: 3,
+			getKeys:   []any{k, k + k, k, k + k},
+			wantHot:   nil,
+		},
+		{
+			name:      "does not count misses",
+			threshold: 1,
+			getKeys:   []any{"nonexistent", k + k},
+			wantHot:   []any{k + k},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []any
+			c, err := New(3, WithHotKeys(tt.threshold, time.Hour, func(key any) {
+				got = append(got, key)
+			}))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			addItems(t, c, [][]any{{k, v}, {k + k, v + v}})
+			for _, key := range tt.getKeys {
+				c.Get(key)
+			}
+			if len(got) != len(tt.wantHot) {
+				t.Fatalf("unexpected hot keys, got %v, want %v", got, tt.wantHot)
+			}
+			for i := range got {
+				if got[i] != tt.wantHot[i] {
+					t.Errorf("unexpected hot keys, got %v, want %v", got, tt.wantHot)
+				}
+			}
+			if keys := c.HotKeys(); len(keys) != len(tt.wantHot) {
+				t.Errorf("unexpected HotKeys(), got %v, want %v", keys, tt.wantHot)
+			}
+		})
+	}
+}
+
+func TestHotKeys_Windows(t *testing.T) {
+	window := int64(time.Minute)
+	tests := []struct {
+		name    string
+		access  map[int64][]string
+		now     int64
+		wantHot []string
+	}{
+		{
+			name:    "keeps hot keys of the previous window",
+			access:  map[int64][]string{0: {"a", "a"}, window: {"b", "b"}},
+			now:     window + 1,
+			wantHot: []string{"a", "b"},
+		},
+		{
+			name:    "drops hot keys of older windows",
+			access:  map[int64][]string{0: {"a", "a"}, window: {"b", "b"}},
+			now:     2*window + 1,
+			wantHot: []string{"b"},
+		},
+		{
+			name:    "resets counts in new window",
+			access:  map[int64][]string{0: {"a"}, window: {"a"}},
+			now:     window + 1,
+			wantHot: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(1, WithHotKeys(2, time.Minute, nil))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			times := make([]int64, 0, len(tt.access))
+			for at := range tt.access {
+				times = append(times, at)
+			}
+			sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+			for _, at := range times {
+				for _, key := range tt.access[at] {
+					c.hot.observe(key, at)
+				}
+			}
+			got := []string{}
+			for _, key := range c.hot.keys(tt.now) {
+				got = append(got, key.(string))
+			}
+			sort.Strings(got)
+			if len(got) != len(tt.wantHot) {
+				t.Fatalf("unexpected hot keys, got %v, want %v", got, tt.wantHot)
+			}
+			for i := range got {
+				if got[i] != tt.wantHot[i] {
+					t.Errorf("unexpected hot keys, got %v, want %v", got, tt.wantHot)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// janitor removes the expired items of the cache periodically. The interval
+// of an adaptive janitor varies between min and interval, see
+// WithAdaptiveJanitor. min is 0 for a janitor with a fixed interval.
+type janitor struct {
+	interval time.Duration
+	min      time.Duration
+	done     chan struct{}
+	once     sync.Once
+
+	// mu guards the state of the goroutine reported by Healthy.
+	mu      sync.Mutex
+	stopped bool
+	perr    *PanicError
+}
+
+// WithJanitor starts a goroutine that removes the expired items every
+// interval, so ClearExpiredData doesn't have to be called manually. The
+// goroutine runs until Close is called, so a cache created with a janitor
+// must be closed when it is no longer used. The interval can be changed with
+// Reconfigure.
+func WithJanitor(interval time.Duration) Option {
+	return func(c *Cache) {
+		if interval <= 0 {
+			return
+		}
+		c.janitor = &janitor{interval: interval}
+	}
+}
+
+// WithAdaptiveJanitor starts a janitor like WithJanitor, whose interval adapts
+// to how many items expire, between min and max. It starts sweeping every min,
+// and the interval is halved after a sweep which removes at least a quarter of
+// the items, and doubled after a sweep which removes none, so the janitor
+// sweeps often while many items expire and backs off when none do. Config
+// reports max as the interval of the janitor, and Reconfigure with another
+// interval replaces it with a janitor with a fixed interval. It is ignored if
+// min is not positive or max is less than min.
+func WithAdaptiveJanitor(min, max time.Duration) Option {
+	return func(c *Cache) {
+		if min <= 0 || max < min {
+			return
+		}
+		c.janitor = &janitor{interval: max, min: min}
+	}
+}
+
+// Close stops the janitor started with WithJanitor and the emergency eviction
+// started with WithEmergencyEviction. The cache can still be used after it is
+// closed, but expired items are no longer removed in the background, and
+// Healthy reports the stopped janitor. A cache created by NewChild no longer
+// follows the capacity of its parent once it is closed. Calling Close more
+// than once, or on a cache without a janitor, is safe.
+func (c *Cache) Close() {
+	c.mu.RLock()
+	j := c.janitor
+	c.mu.RUnlock()
+	if j != nil {
+		j.stop()
+	}
+	if c.emergency != nil {
+		c.emergency.stop()
+	}
+	if c.parent != nil {
+		c.parent.removeChild(c)
+	}
+}
+
+// startJanitor starts the goroutine of the janitor.
+func (c *Cache) startJanitor() {
+	j := c.janitor
+	j.done = make(chan struct{})
+	go c.labeled("janitor", func(context.Context) {
+		defer func() {
+			j.mu.Lock()
+			j.stopped = true
+			j.mu.Unlock()
+		}()
+		interval := j.interval
+		if j.min != 0 {
+			interval = j.min
+		}
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+		for {
+			select {
+			case <-timer.C:
+				var removed, swept int
+				if perr := c.guard("janitor", func() { removed, swept = c.sweep() }); perr != nil {
+					j.mu.Lock()
+					j.perr = perr
+					j.mu.Unlock()
+				}
+				interval = j.next(interval, removed, swept)
+				timer.Reset(interval)
+			case <-j.done:
+				return
+			}
+		}
+	})
+}
+
+// sweep removes the expired items. It returns the number of removed items
+// and the number of items before removing them.
+func (c *Cache) sweep() (removed, swept int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	swept = c.len
+	c.clearExpiredData(time.Now().UnixNano())
+	return swept - c.len, swept
+}
+
+// next returns the interval after a sweep which removed the given number of
+// the swept items. The interval of a janitor which is not adaptive is fixed.
+func (j *janitor) next(interval time.Duration, removed, swept int) time.Duration {
+	switch {
+	case j.min == 0:
+		return interval
+	case removed == 0 && interval < j.interval:
+		interval *= 2
+		if interval > j.interval {
+			interval = j.interval
+		}
+	case removed > 0 && removed*4 >= swept && interval > j.min:
+		interval /= 2
+		if interval < j.min {
+			interval = j.min
+		}
+	}
+	return interval
+}
+
+// setJanitorInterval starts, stops or restarts the janitor, so it sweeps
+// every interval, or never if interval is 0. It is called with the lock held.
+func (c *Cache) setJanitorInterval(interval time.Duration) {
+	if c.janitor != nil && c.janitor.interval == interval {
+		return
+	}
+	if c.janitor != nil {
+		c.janitor.stop()
+		c.janitor = nil
+	}
+	if interval > 0 {
+		c.janitor = &janitor{interval: interval}
+		c.startJanitor()
+	}
+}
+
+// stop stops the goroutine of the janitor.
+func (j *janitor) stop() {
+	j.once.Do(func() { close(j.done) })
+}
+
+// err returns error if the goroutine of the janitor is stopped, or one of its
+// sweeps panicked.
+func (j *janitor) err() error {
+	select {
+	case <-j.done:
+		return errJanitorStopped
+	default:
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.stopped {
+		return errJanitorStopped
+	}
+	if j.perr != nil {
+		return fmt.Errorf("%w: %v", errJanitorPanicked, j.perr)
+	}
+	return nil
+}
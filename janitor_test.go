@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithJanitor(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []Option
+		wantLength int
+	}{
+		{
+			name:       "removes expired items in background",
+			opts:       []Option{WithJanitor(10 * time.Millisecond)},
+			wantLength: 1,
+		},
+		{
+			name:       "keeps expired items without janitor",
+			opts:       nil,
+			wantLength: 2,
+		},
+		{
+			name:       "ignores non-positive interval",
+			opts:       []Option{WithJanitor(0)},
+			wantLength: 2,
+		},
+		{
+			name:       "removes expired items with adaptive interval",
+			opts:       []Option{WithAdaptiveJanitor(5*time.Millisecond, time.Second)},
+			wantLength: 1,
+		},
+		{
+			name:       "ignores adaptive bounds out of order",
+			opts:       []Option{WithAdaptiveJanitor(10*time.Millisecond, time.Millisecond)},
+			wantLength: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(3, tt.opts...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			defer c.Close()
+			addItemsWithExp(t, c, [][]any{{k, v, time.Duration(0)}, {k + k, v + v, 20 * time.Millisecond}})
+			time.Sleep(100 * time.Millisecond)
+			if c.Len() != tt.wantLength {
+				t.Errorf("unexpected length, got %v, want %v", c.Len(), tt.wantLength)
+			}
+		})
+	}
+}
+
+func TestJanitor_Next(t *testing.T) {
+	tests := []struct {
+		name     string
+		min      time.Duration
+		max      time.Duration
+		interval time.Duration
+		removed  int
+		swept    int
+		want     time.Duration
+	}{
+		{
+			name:     "keeps fixed interval",
+			max:      time.Second,
+			interval: time.Second,
+			swept:    10,
+			want:     time.Second,
+		},
+		{
+			name:     "backs off when nothing expires",
+			min:      100 * time.Millisecond,
+			max:      time.Second,
+			interval: 100 * time.Millisecond,
+			swept:    10,
+			want:     200 * time.Millisecond,
+		},
+		{
+			name:     "backs off up to max",
+			min:      100 * time.Millisecond,
+			max:      time.Second,
+			interval: 800 * time.Millisecond,
+			want:     time.Second,
+		},
+		{
+			name:     "sweeps more often when many items expire",
+			min:      100 * time.Millisecond,
+			max:      time.Second,
+			interval: time.Second,
+			removed:  3,
+			swept:    10,
+			want:     500 * time.Millisecond,
+		},
+		{
+			name:     "sweeps at most every min",
+			min:      100 * time.Millisecond,
+			max:      time.Second,
+			interval: 150 * time.Millisecond,
+			removed:  10,
+			swept:    10,
+			want:     100 * time.Millisecond,
+		},
+		{
+			name:     "keeps interval when few items expire",
+			min:      100 * time.Millisecond,
+			max:      time.Second,
+			interval: 400 * time.Millisecond,
+			removed:  1,
+			swept:    10,
+			want:     400 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := &janitor{interval: tt.max, min: tt.min}
+			if got := j.next(tt.interval, tt.removed, tt.swept); got != tt.want {
+				t.Errorf("janitor.next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCache_Close(t *testing.T) {
+	c, err := New(3, WithJanitor(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	c.Close()
+	c.Close()
+	addItemsWithExp(t, c, [][]any{{k, v, 10 * time.Millisecond}})
+	time.Sleep(50 * time.Millisecond)
+	if c.Len() != 1 {
+		t.Errorf("expected closed janitor to keep expired item, got length %v", c.Len())
+	}
+}
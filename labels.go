@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// WithName sets the name of the cache. It tells caches apart in CPU profiles,
+// where the goroutines started by the cache, like the janitor, the scheduled
+// jobs and the configuration watcher, run with the pprof labels "cache", set
+// to the name, and "op", set to the operation like "janitor" or "refresh".
+// The read repair function and the loader of GetOrLoad run on the goroutine
+// of the caller, labeled the same way. The labels of the context passed with
+// Context are kept besides them, other labels of the goroutine are cleared
+// when the call returns, so callers using labels should pass their context.
+func WithName(name string) Option {
+	return func(c *Cache) {
+		c.name = name
+	}
+}
+
+// labeled calls fn with the goroutine labeled for CPU profiles with the name
+// of the cache and the given operation. fn receives the context holding the
+// labels. It is only for the goroutines started by the cache, since the
+// goroutine is left without labels when fn returns.
+func (c *Cache) labeled(op string, fn func(ctx context.Context)) {
+	pprof.Do(context.Background(), pprof.Labels("cache", c.name, "op", op), fn)
+}
+
+// labeledCaller is labeled for the goroutine of a caller, which may have
+// passed ctx with Context. The labels of ctx are kept besides the ones of the
+// cache, and they are restored when fn returns. If ctx is nil, the labels of
+// the goroutine are unknown, so it is left without labels when fn returns.
+func (c *Cache) labeledCaller(ctx context.Context, op string, fn func(ctx context.Context)) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	pprof.Do(ctx, pprof.Labels("cache", c.name, "op", op), fn)
+}
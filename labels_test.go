@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestCache_labeled(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		op        string
+		wantCache string
+	}{
+		{
+			name:      "labels with name of cache",
+			opts:      []Option{WithName("users")},
+			op:        "compact",
+			wantCache: "users",
+		},
+		{
+			name:      "labels with empty name by default",
+			op:        "repair",
+			wantCache: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(1, tt.opts...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			called := false
+			c.labeled(tt.op, func(ctx context.Context) {
+				called = true
+				if got, _ := pprof.Label(ctx, "cache"); got != tt.wantCache {
+					t.Errorf("unexpected cache label, got %q, want %q", got, tt.wantCache)
+				}
+				if got, _ := pprof.Label(ctx, "op"); got != tt.op {
+					t.Errorf("unexpected op label, got %q, want %q", got, tt.op)
+				}
+			})
+			if !called {
+				t.Errorf("expected function to be called")
+			}
+		})
+	}
+}
+
+func TestCache_labeledCaller(t *testing.T) {
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantCache string
+		wantOp    string
+		wantApp   string
+	}{
+		{
+			name:      "keeps labels of caller context",
+			ctx:       pprof.WithLabels(context.Background(), pprof.Labels("app", "api")),
+			wantCache: "users",
+			wantOp:    "load",
+			wantApp:   "api",
+		},
+		{
+			name:      "labels without caller context",
+			ctx:       nil,
+			wantCache: "users",
+			wantOp:    "load",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(1, WithName("users"))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			c.labeledCaller(tt.ctx, "load", func(ctx context.Context) {
+				for key, want := range map[string]string{"cache": tt.wantCache, "op": tt.wantOp, "app": tt.wantApp} {
+					if got, _ := pprof.Label(ctx, key); got != want {
+						t.Errorf("unexpected %s label, got %q, want %q", key, got, want)
+					}
+				}
+			})
+		})
+	}
+}
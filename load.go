@@ -0,0 +1,333 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// GetOrLoad returns the value of the key if it is in the cache. Otherwise it
+// calls loader, adds the loaded value with the expiration duration exp like
+// Add, and returns it. The loader is called without holding the lock of the
+// cache, so it may be slow or call other methods of the cache. If the loader
+// returns error, nothing is added and the error is returned. The loaded value
+// is returned even if it is not added, e.g. when it is not admitted, unless
+// it is rejected by the validator set with WithValidator or can't be encoded
+// by the codecs set with WithValueCodecs.
+//
+// Concurrent calls missing the same key are coalesced, so only one loader per
+// key runs at a time. The other callers wait for it and receive its result,
+// including its error. A waiter which passed its context with Context stops
+// waiting and returns the error of the context when it is done, while the load
+// goes on for the others. The calls whose context is marked with Bypass or
+// NoStore, see Context, always call their own loader and don't store its
+// value.
+//
+// There is no loader set for the whole cache, each call passes its own. A call
+// site which needs special fetch logic passes a different loader, and it still
+// shares the items and the coalescing of the key with the other call sites.
+// Coalesced calls receive the value of the loader which runs, whichever call
+// site passed it.
+func (c *Cache) GetOrLoad(key interface{}, exp time.Duration, loader func(key interface{}) (interface{}, error), opts ...CallOption) (interface{}, error) {
+	return c.GetOrLoadResult(key, func(key interface{}) (LoadResult, error) {
+		val, err := loader(key)
+		return LoadResult{Val: val, TTL: exp}, err
+	}, opts...)
+}
+
+// LoadResult is a value loaded by the loader of GetOrLoadResult with the way
+// it is cached, so the origin can decide it, e.g. by the Cache-Control header
+// of a backend.
+type LoadResult struct {
+	// Val is the loaded value.
+	Val interface{}
+
+	// TTL is the expiration duration of the item, like the one passed to Add.
+	TTL time.Duration
+
+	// SoftTTL makes the item stale after it, see SoftTTL. It is ignored if it
+	// is 0.
+	SoftTTL time.Duration
+
+	// Cost is the cost of the item, see AddWithCost. If it is 0, the item is
+	// added like Add.
+	Cost int64
+
+	// Source tags the item, see Source. It is "loader" if it is empty.
+	Source string
+
+	// NoStore makes the value returned without being stored, like NoStore.
+	NoStore bool
+}
+
+// GetOrLoadResult is like GetOrLoad, but the loader returns how the value is
+// cached with it, see LoadResult. It returns error if the cost of the result
+// is negative.
+func (c *Cache) GetOrLoadResult(key interface{}, loader func(key interface{}) (LoadResult, error), opts ...CallOption) (val interface{}, err error) {
+	defer recoverCorrupt(&err)
+	if val, found := c.Get(key, opts...); found {
+		return val, nil
+	}
+	cfg := newCallConfig(opts)
+	defer func() {
+		if err == nil {
+			cfg.serve(FromLoader, 0)
+		}
+	}()
+	if cfg.noStore {
+		res, err := c.load(cfg.ctx, key, loader)
+		return res.Val, err
+	}
+	val, waited, err := c.flight.do(cfg.ctx, key, func() (interface{}, error) {
+		res, err := c.load(cfg.ctx, key, loader)
+		if err != nil {
+			return nil, err
+		}
+		if res.NoStore {
+			return res.Val, nil
+		}
+		if err := c.store(key, res, opts); err != nil {
+			return nil, err
+		}
+		return res.Val, nil
+	})
+	if waited {
+		c.coalesced(key)
+	}
+	return val, err
+}
+
+// store adds the loaded value as the result tells. It returns the errors
+// which make the value unusable, i.e. validation and encoding errors, and the
+// error of a negative cost.
+func (c *Cache) store(key interface{}, res LoadResult, opts []CallOption) error {
+	source := res.Source
+	if source == "" {
+		source = "loader"
+	}
+	opts = append(opts[:len(opts):len(opts)], Source(source))
+	if res.SoftTTL != 0 {
+		opts = append(opts, SoftTTL(res.SoftTTL))
+	}
+	var err error
+	if res.Cost != 0 {
+		err = c.AddWithCost(key, res.Val, res.Cost, res.TTL, opts...)
+	} else {
+		err = c.Add(key, res.Val, res.TTL, opts...)
+	}
+	var verr *ValidationError
+	if errors.As(err, &verr) || errors.Is(err, errNotBytes) || errors.Is(err, errNegativeCost) {
+		return err
+	}
+	return nil
+}
+
+// Origin tells where a value returned by Get or GetOrLoad comes from, see
+// Served.
+type Origin int
+
+const (
+	// FromCache is the value of an item which is fresh, either since it has
+	// no soft TTL or its soft TTL didn't pass.
+	FromCache Origin = iota
+
+	// FromStale is the value of an item whose soft TTL passed, see SoftTTL.
+	FromStale
+
+	// FromLoader is the value returned by the loader of GetOrLoad, either the
+	// one of the call or the one of a call coalesced with it.
+	FromLoader
+)
+
+// String returns the name of the origin, like "stale", which can be used in
+// headers like X-Cache.
+func (o Origin) String() string {
+	switch o {
+	case FromCache:
+		return "cache"
+	case FromStale:
+		return "stale"
+	case FromLoader:
+		return "loader"
+	default:
+		return fmt.Sprintf("Origin(%d)", int(o))
+	}
+}
+
+// ServeInfo describes a value returned by Get or GetOrLoad, see Served.
+type ServeInfo struct {
+	// Origin is where the value comes from.
+	Origin Origin
+
+	// Age is the time since the item was added or refreshed, which can be
+	// used as the Age header of HTTP. It is 0 for loaded and repaired values,
+	// and if the cache is created with WithCompactItems.
+	Age time.Duration
+}
+
+// Served makes Get and GetOrLoad store in info where the returned value comes
+// from and how old it is, so HTTP handlers can set headers like Age and
+// X-Cache accurately. info is meaningful only if a value is returned.
+func Served(info *ServeInfo) CallOption {
+	return func(cfg *callConfig) {
+		cfg.served = info
+	}
+}
+
+// serve stores the origin and the age of the returned value in the ServeInfo
+// passed with Served. created is the creation time of the item, or 0.
+func (cfg callConfig) serve(origin Origin, created int64) {
+	if cfg.served == nil {
+		return
+	}
+	cfg.served.Origin = origin
+	cfg.served.Age = 0
+	if created != 0 {
+		cfg.served.Age = time.Duration(time.Now().UnixNano() - created)
+	}
+}
+
+// coalesced records a GetOrLoad call which received the result of the loader
+// of another call.
+func (c *Cache) coalesced(key interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.LoadsCoalesced++
+	if ns := c.namespaceStats(c.namespace(key)); ns != nil {
+		ns.LoadsCoalesced++
+	}
+}
+
+// WithMaxConcurrentLoads limits the loaders of GetOrLoad running at the same
+// time across all keys to n, to protect a fragile origin from storms of misses
+// on distinct keys, which coalescing doesn't help with. Calls beyond the limit
+// wait until a running loader returns. A caller which passed its context with
+// Context stops waiting and returns the error of the context when it is done,
+// and the calls coalesced with it load the value themselves. The time spent
+// waiting is not counted in LoadTime. n less than 1 is ignored.
+func WithMaxConcurrentLoads(n int) Option {
+	return func(c *Cache) {
+		if n < 1 {
+			return
+		}
+		c.loadSlots = make(chan struct{}, n)
+	}
+}
+
+// flight coalesces the concurrent loads of the same key.
+type flight struct {
+	mu    sync.Mutex
+	calls map[interface{}]*flightCall
+}
+
+// flightCall is a load in progress. done is closed when the load completes.
+// The load is abandoned if it fails since the context of its caller is done,
+// in which case the waiters load the value themselves.
+type flightCall struct {
+	done      chan struct{}
+	val       interface{}
+	err       error
+	abandoned bool
+}
+
+// do calls fn for the key unless a call for the key is in progress, in which
+// case it waits for that call and returns its result. A waiter stops waiting
+// when ctx is done, if ctx is not nil, and it calls fn itself if the call it
+// waits for is abandoned. It reports whether the result is received from
+// another call.
+func (f *flight) do(ctx context.Context, key interface{}, fn func() (interface{}, error)) (val interface{}, waited bool, err error) {
+	var ctxDone <-chan struct{}
+	if ctx != nil {
+		ctxDone = ctx.Done()
+	}
+	f.mu.Lock()
+	for {
+		call, ok := f.calls[key]
+		if !ok {
+			break
+		}
+		f.mu.Unlock()
+		select {
+		case <-call.done:
+			if !call.abandoned {
+				return call.val, true, call.err
+			}
+		case <-ctxDone:
+			return nil, false, ctx.Err()
+		}
+		f.mu.Lock()
+	}
+	if f.calls == nil {
+		f.calls = make(map[interface{}]*flightCall)
+	}
+	call := &flightCall{done: make(chan struct{}), err: errLoadPanicked}
+	f.calls[key] = call
+	f.mu.Unlock()
+
+	// The waiters are released even if fn panics.
+	defer func() {
+		f.mu.Lock()
+		delete(f.calls, key)
+		f.mu.Unlock()
+		close(call.done)
+	}()
+	call.val, call.err = fn()
+	call.abandoned = ctx != nil && ctx.Err() != nil && errors.Is(call.err, ctx.Err())
+	return call.val, false, call.err
+}
+
+// load calls the loader for the key and records the result. ctx is the
+// context passed with Context, or nil.
+func (c *Cache) load(ctx context.Context, key interface{}, loader func(key interface{}) (LoadResult, error)) (LoadResult, error) {
+	if c.loadSlots != nil {
+		if err := c.acquireLoad(ctx); err != nil {
+			return LoadResult{}, err
+		}
+		defer func() { <-c.loadSlots }()
+	}
+	var res LoadResult
+	var err error
+	start := time.Now()
+	c.labeledCaller(ctx, "load", func(context.Context) {
+		if perr := c.guard("load", func() { res, err = loader(key) }); perr != nil {
+			err = perr
+		}
+	})
+	elapsed := time.Since(start)
+	if c.slowFn != nil {
+		c.reportSlow("load", start, false)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range []*Stats{&c.stats, c.namespaceStats(c.namespace(key))} {
+		if s == nil {
+			continue
+		}
+		if err != nil {
+			s.LoadErrors++
+		} else {
+			s.Loads++
+		}
+		s.LoadTime += elapsed
+	}
+	return res, err
+}
+
+// acquireLoad waits for a free slot of the loaders set with
+// WithMaxConcurrentLoads. It returns the error of ctx if ctx is done first.
+// ctx may be nil.
+func (c *Cache) acquireLoad(ctx context.Context) error {
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+	select {
+	case c.loadSlots <- struct{}{}:
+		return nil
+	case <-done:
+		return ctx.Err()
+	}
+}
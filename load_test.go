@@ -0,0 +1,431 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache_GetOrLoad(t *testing.T) {
+	errLoad := errors.New("load failed")
+	tests := []struct {
+		name      string
+		opts      []Option
+		addPairs  [][]any
+		loadVal   any
+		loadErr   error
+		wantVal   any
+		wantErr   error
+		wantCalls int
+		wantFound bool
+		wantStats Stats
+	}{
+		{
+			name:      "returns cached value without loading",
+			addPairs:  [][]any{{k, v}},
+			loadVal:   v + v,
+			wantVal:   v,
+			wantFound: true,
+			wantStats: Stats{Hits: 1},
+		},
+		{
+			name:      "loads and adds missing value",
+			loadVal:   v,
+			wantVal:   v,
+			wantCalls: 1,
+			wantFound: true,
+			wantStats: Stats{Misses: 1, Loads: 1},
+		},
+		{
+			name:      "returns loader error",
+			loadErr:   errLoad,
+			wantErr:   errLoad,
+			wantCalls: 1,
+			wantStats: Stats{Misses: 1, LoadErrors: 1},
+		},
+		{
+			name:      "returns value which is not admitted",
+			opts:      []Option{WithAdmitFunc(func(key, val any, exp time.Duration) bool { return false })},
+			loadVal:   v,
+			wantVal:   v,
+			wantCalls: 1,
+			wantStats: Stats{Misses: 1, Loads: 1},
+		},
+		{
+			name:      "returns validation error",
+			opts:      []Option{WithValidator(func(key, val any) error { return errLoad })},
+			loadVal:   v,
+			wantErr:   errLoad,
+			wantCalls: 1,
+			wantStats: Stats{Misses: 1, Loads: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(3, tt.opts...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			addItems(t, c, tt.addPairs)
+			calls := 0
+			val, err := c.GetOrLoad(k, 0, func(key any) (any, error) {
+				calls++
+				return tt.loadVal, tt.loadErr
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error, got %v, want %v", err, tt.wantErr)
+			}
+			if val != tt.wantVal {
+				t.Errorf("unexpected value, got %v, want %v", val, tt.wantVal)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("unexpected loader calls, got %v, want %v", calls, tt.wantCalls)
+			}
+			if found := c.Contains(k); found != tt.wantFound {
+				t.Errorf("unexpected found, got %v, want %v", found, tt.wantFound)
+			}
+			stats := c.Stats()
+			stats.LoadTime = 0
+			if stats != tt.wantStats {
+				t.Errorf("unexpected stats, got %+v, want %+v", stats, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestCache_GetOrLoadCoalesces(t *testing.T) {
+	errLoad := errors.New("load failed")
+	tests := []struct {
+		name    string
+		loadVal any
+		loadErr error
+		wantVal any
+		wantErr error
+	}{
+		{
+			name:    "shares loaded value",
+			loadVal: v,
+			wantVal: v,
+		},
+		{
+			name:    "shares loader error",
+			loadErr: errLoad,
+			wantErr: errLoad,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := createCache(t, 3)
+			const callers = 10
+			var calls int32
+			release := make(chan struct{})
+			var wg sync.WaitGroup
+			for i := 0; i < callers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					val, err := c.GetOrLoad(k, 0, func(key any) (any, error) {
+						atomic.AddInt32(&calls, 1)
+						<-release
+						return tt.loadVal, tt.loadErr
+					})
+					if val != tt.wantVal || !errors.Is(err, tt.wantErr) {
+						t.Errorf("unexpected result, got %v, %v, want %v, %v", val, err, tt.wantVal, tt.wantErr)
+					}
+				}()
+			}
+			// Give the callers time to miss before the load completes.
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+			if calls != 1 {
+				t.Errorf("unexpected loader calls, got %v, want 1", calls)
+			}
+			if got := c.Stats().LoadsCoalesced; got != callers-1 {
+				t.Errorf("unexpected coalesced loads, got %v, want %v", got, callers-1)
+			}
+		})
+	}
+}
+
+func TestCache_GetOrLoadWaiterCanceled(t *testing.T) {
+	c := createCache(t, 3)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loader := func(key any) (any, error) {
+		close(started)
+		<-release
+		return v, nil
+	}
+	leader := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrLoad(k, 0, loader)
+		leader <- err
+	}()
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrLoad(k, 0, loader, Context(ctx))
+		canceled <- err
+	}()
+	waiter := make(chan any, 1)
+	go func() {
+		val, _ := c.GetOrLoad(k, 0, loader)
+		waiter <- val
+	}()
+
+	cancel()
+	select {
+	case err := <-canceled:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("unexpected error of canceled waiter, got %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("canceled waiter is still waiting for the load")
+	}
+
+	close(release)
+	if err := <-leader; err != nil {
+		t.Errorf("unexpected error of loading caller, got %v", err)
+	}
+	if val := <-waiter; val != v {
+		t.Errorf("unexpected value of other waiter, got %v, want %v", val, v)
+	}
+	if val, _ := c.Get(k); val != v {
+		t.Errorf("unexpected cached value, got %v, want %v", val, v)
+	}
+}
+
+func TestWithMaxConcurrentLoads(t *testing.T) {
+	c, err := New(10, WithMaxConcurrentLoads(2))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	var mu sync.Mutex
+	var running, peak int
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(key int) {
+			defer wg.Done()
+			val, err := c.GetOrLoad(key, 0, func(key any) (any, error) {
+				mu.Lock()
+				if running++; running > peak {
+					peak = running
+				}
+				mu.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return v, nil
+			})
+			if val != v || err != nil {
+				t.Errorf("unexpected result, got %v, %v, want %v, %v", val, err, v, nil)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if peak > 2 {
+		t.Errorf("unexpected concurrent loaders, got %v, want at most %v", peak, 2)
+	}
+	if c.Len() != 5 {
+		t.Errorf("unexpected length, got %v, want %v", c.Len(), 5)
+	}
+}
+
+func TestWithMaxConcurrentLoads_Canceled(t *testing.T) {
+	c, err := New(10, WithMaxConcurrentLoads(1))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go c.GetOrLoad("busy", 0, func(key any) (any, error) {
+		close(started)
+		<-release
+		return v, nil
+	})
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	queued := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrLoad(k, 0, func(key any) (any, error) { return v, nil }, Context(ctx))
+		queued <- err
+	}()
+	// Give the caller time to queue before the other one joins it.
+	time.Sleep(20 * time.Millisecond)
+	waiter := make(chan any, 1)
+	go func() {
+		val, _ := c.GetOrLoad(k, 0, func(key any) (any, error) { return v + v, nil })
+		waiter <- val
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	if err := <-queued; !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected error of canceled caller, got %v, want %v", err, context.Canceled)
+	}
+	close(release)
+	if val := <-waiter; val != v+v {
+		t.Errorf("expected waiter to load the value itself, got %v, want %v", val, v+v)
+	}
+}
+
+func TestServed(t *testing.T) {
+	c := createCache(t, 3)
+	if err := c.Add(k, v, 0); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := c.Add(k+k, v, 0, SoftTTL(time.Nanosecond)); err != nil {
+		t.Fatalf(err.Error())
+	}
+	time.Sleep(5 * time.Millisecond)
+	loader := func(key any) (any, error) { return v, nil }
+
+	tests := []struct {
+		name       string
+		key        any
+		wantOrigin Origin
+		wantAged   bool
+	}{
+		{
+			name:       "reports fresh item with its age",
+			key:        k,
+			wantOrigin: FromCache,
+			wantAged:   true,
+		},
+		{
+			name:       "reports stale item with its age",
+			key:        k + k,
+			wantOrigin: FromStale,
+			wantAged:   true,
+		},
+		{
+			name:       "reports loaded value",
+			key:        k + k + k,
+			wantOrigin: FromLoader,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var info ServeInfo
+			if _, err := c.GetOrLoad(tt.key, 0, loader, Served(&info)); err != nil {
+				t.Fatalf(err.Error())
+			}
+			if info.Origin != tt.wantOrigin {
+				t.Errorf("unexpected origin, got %v, want %v", info.Origin, tt.wantOrigin)
+			}
+			if aged := info.Age >= 5*time.Millisecond; aged != tt.wantAged {
+				t.Errorf("unexpected age, got %v, want aged %v", info.Age, tt.wantAged)
+			}
+		})
+	}
+}
+
+func TestCache_GetOrLoadResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		res        LoadResult
+		wantErr    error
+		wantFound  bool
+		wantExp    bool
+		wantSoft   bool
+		wantCost   int64
+		wantSource string
+	}{
+		{
+			name:       "adds value like Add",
+			res:        LoadResult{Val: v},
+			wantFound:  true,
+			wantCost:   1,
+			wantSource: "loader",
+		},
+		{
+			name:       "adds value with policy of result",
+			res:        LoadResult{Val: v, TTL: time.Hour, SoftTTL: time.Minute, Cost: 5, Source: "origin"},
+			wantFound:  true,
+			wantExp:    true,
+			wantSoft:   true,
+			wantCost:   5,
+			wantSource: "origin",
+		},
+		{
+			name: "returns value without storing it",
+			res:  LoadResult{Val: v, NoStore: true},
+		},
+		{
+			name:    "returns error for negative cost",
+			res:     LoadResult{Val: v, Cost: -1},
+			wantErr: errNegativeCost,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(3, WithMaxCost(10))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			val, err := c.GetOrLoadResult(k, func(key any) (LoadResult, error) { return tt.res, nil })
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error, got %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && val != v {
+				t.Errorf("unexpected value, got %v, want %v", val, v)
+			}
+			item, found := c.PeekWithInfo(k)
+			if found != tt.wantFound {
+				t.Fatalf("unexpected found, got %v, want %v", found, tt.wantFound)
+			}
+			if !found {
+				return
+			}
+			if (item.Expiration != 0) != tt.wantExp || (item.SoftExpiration != 0) != tt.wantSoft {
+				t.Errorf("unexpected expiration, got %+v, want expiration %v and soft expiration %v", item, tt.wantExp, tt.wantSoft)
+			}
+			if item.Cost != tt.wantCost || item.Source != tt.wantSource {
+				t.Errorf("unexpected item, got cost %v and source %q, want %v and %q", item.Cost, item.Source, tt.wantCost, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestCache_GetOrLoadCallSiteLoaders(t *testing.T) {
+	c := createCache(t, 3)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan any, 1)
+	go func() {
+		val, _ := c.GetOrLoad(k, 0, func(key any) (any, error) {
+			close(started)
+			<-release
+			return v, nil
+		})
+		done <- val
+	}()
+	<-started
+
+	special := make(chan any, 1)
+	go func() {
+		val, _ := c.GetOrLoad(k, 0, func(key any) (any, error) { return v + v, nil })
+		special <- val
+	}()
+	// Give the call time to join the running load.
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	if val := <-done; val != v {
+		t.Errorf("unexpected value, got %v, want %v", val, v)
+	}
+	if val := <-special; val != v {
+		t.Errorf("expected coalesced call to receive the running load, got %v, want %v", val, v)
+	}
+	if val, _ := c.GetOrLoad(k, 0, func(key any) (any, error) { return v + v, nil }); val != v {
+		t.Errorf("expected call site loader to share the stored item, got %v, want %v", val, v)
+	}
+}
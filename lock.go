@@ -0,0 +1,181 @@
+package cache
+
+import "sync"
+
+// LockMode selects how the cache synchronizes concurrent operations.
+// Operations that only read the cache (Contains, Peek, Keys) take the read
+// side of the lock. Get takes the write side since it updates the access
+// order.
+type LockMode int
+
+const (
+	// LockExclusive serializes every operation with a single mutex. It is the
+	// default mode.
+	LockExclusive LockMode = iota
+
+	// LockWritePreferring lets readers run concurrently. A waiting writer
+	// blocks new readers, so writers never starve under heavy read load.
+	LockWritePreferring
+
+	// LockReadPreferring lets readers run concurrently and admits new readers
+	// even when a writer is waiting. It gives the best read throughput, but
+	// writers may starve while readers keep arriving.
+	LockReadPreferring
+
+	// LockFair grants the lock in arrival order. Consecutive readers still
+	// share the lock, but nobody can overtake a waiting writer or reader.
+	LockFair
+)
+
+// WithLockMode sets the locking strategy of the cache. See LockMode for the
+// available modes.
+func WithLockMode(mode LockMode) Option {
+	return func(c *Cache) {
+		c.mu = newLocker(mode)
+	}
+}
+
+// locker is a readers-writer lock.
+type locker interface {
+	Lock()
+	Unlock()
+	RLock()
+	RUnlock()
+}
+
+// newLocker returns the locker implementing the given mode.
+func newLocker(mode LockMode) locker {
+	switch mode {
+	case LockWritePreferring:
+		// sync.RWMutex blocks new readers once a writer is waiting.
+		return &sync.RWMutex{}
+	case LockReadPreferring:
+		l := &readPreferringLocker{}
+		l.cond = sync.NewCond(&l.mu)
+		return l
+	case LockFair:
+		l := &fairLocker{}
+		l.cond = sync.NewCond(&l.mu)
+		return l
+	default:
+		return &mutexLocker{}
+	}
+}
+
+// mutexLocker is an exclusive lock. Readers take the lock exclusively too.
+type mutexLocker struct {
+	sync.Mutex
+}
+
+// RLock locks the mutex exclusively.
+func (l *mutexLocker) RLock() {
+	l.Lock()
+}
+
+// RUnlock unlocks the mutex.
+func (l *mutexLocker) RUnlock() {
+	l.Unlock()
+}
+
+// readPreferringLocker is a readers-writer lock where readers only wait for
+// an active writer, never for a waiting one.
+type readPreferringLocker struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	readers int
+	writing bool
+}
+
+// Lock waits until there are no readers and no writer.
+func (l *readPreferringLocker) Lock() {
+	l.mu.Lock()
+	for l.writing || l.readers > 0 {
+		l.cond.Wait()
+	}
+	l.writing = true
+	l.mu.Unlock()
+}
+
+// Unlock releases the write lock.
+func (l *readPreferringLocker) Unlock() {
+	l.mu.Lock()
+	l.writing = false
+	l.cond.Broadcast()
+	l.mu.Unlock()
+}
+
+// RLock waits until there is no active writer.
+func (l *readPreferringLocker) RLock() {
+	l.mu.Lock()
+	for l.writing {
+		l.cond.Wait()
+	}
+	l.readers++
+	l.mu.Unlock()
+}
+
+// RUnlock releases a read lock.
+func (l *readPreferringLocker) RUnlock() {
+	l.mu.Lock()
+	l.readers--
+	if l.readers == 0 {
+		l.cond.Broadcast()
+	}
+	l.mu.Unlock()
+}
+
+// fairLocker is a ticket based readers-writer lock. Every caller takes a
+// ticket and the lock is granted in ticket order.
+type fairLocker struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	next    uint64
+	serving uint64
+	readers int
+	writing bool
+}
+
+// Lock waits for its turn and until there are no readers and no writer.
+func (l *fairLocker) Lock() {
+	l.mu.Lock()
+	ticket := l.next
+	l.next++
+	for ticket != l.serving || l.writing || l.readers > 0 {
+		l.cond.Wait()
+	}
+	l.writing = true
+	l.serving++
+	l.mu.Unlock()
+}
+
+// Unlock releases the write lock.
+func (l *fairLocker) Unlock() {
+	l.mu.Lock()
+	l.writing = false
+	l.cond.Broadcast()
+	l.mu.Unlock()
+}
+
+// RLock waits for its turn and until there is no writer.
+func (l *fairLocker) RLock() {
+	l.mu.Lock()
+	ticket := l.next
+	l.next++
+	for ticket != l.serving || l.writing {
+		l.cond.Wait()
+	}
+	l.readers++
+	l.serving++
+	l.cond.Broadcast()
+	l.mu.Unlock()
+}
+
+// RUnlock releases a read lock.
+func (l *fairLocker) RUnlock() {
+	l.mu.Lock()
+	l.readers--
+	if l.readers == 0 {
+		l.cond.Broadcast()
+	}
+	l.mu.Unlock()
+}
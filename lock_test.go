@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCache_WithLockMode(t *testing.T) {
+	tests := []struct {
+		name string
+		mode LockMode
+	}{
+		{
+			name: "exclusive lock mode handles concurrent operations",
+			mode: LockExclusive,
+		},
+		{
+			name: "write-preferring lock mode handles concurrent operations",
+			mode: LockWritePreferring,
+		},
+		{
+			name: "read-preferring lock mode handles concurrent operations",
+			mode: LockReadPreferring,
+		},
+		{
+			name: "fair lock mode handles concurrent operations",
+			mode: LockFair,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(10, WithLockMode(tt.mode))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					for j := 0; j < 100; j++ {
+						_ = c.Add(i*100+j, j, 0)
+						c.Get(i*100 + j)
+						c.Peek(j)
+						c.Contains(j)
+						c.Keys()
+					}
+				}(i)
+			}
+			wg.Wait()
+			if c.Len() != c.lst.Len() {
+				t.Errorf("incorrect cache length, got %v, want %v", c.Len(), c.lst.Len())
+			}
+		})
+	}
+}
+
+func TestLocker(t *testing.T) {
+	modes := []LockMode{LockExclusive, LockWritePreferring, LockReadPreferring, LockFair}
+	for _, mode := range modes {
+		l := newLocker(mode)
+		var (
+			wg      sync.WaitGroup
+			writing int32
+			counter int
+		)
+		for i := 0; i < 4; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 200; j++ {
+					l.Lock()
+					if !atomic.CompareAndSwapInt32(&writing, 0, 1) {
+						t.Errorf("mode %v: writer entered while another writer holds the lock", mode)
+					}
+					counter++
+					atomic.StoreInt32(&writing, 0)
+					l.Unlock()
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 200; j++ {
+					l.RLock()
+					if atomic.LoadInt32(&writing) != 0 {
+						t.Errorf("mode %v: reader entered while a writer holds the lock", mode)
+					}
+					l.RUnlock()
+				}
+			}()
+		}
+		wg.Wait()
+		if counter != 800 {
+			t.Errorf("mode %v: unexpected counter, got %v, want %v", mode, counter, 800)
+		}
+	}
+}
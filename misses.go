@@ -0,0 +1,48 @@
+package cache
+
+// missCause is why a key is not in the cache anymore.
+type missCause int
+
+const (
+	missExpired missCause = iota
+	missEvicted
+	missInvalidated
+)
+
+// WithMissClassification makes the cache remember why the items of the last
+// size removed keys are removed, so the misses are counted by cause in Stats:
+// MissesExpired, MissesEvicted, MissesInvalidated and MissesNeverPresent.
+// Misses on keys removed before the last size removals, or by Clear, count
+// as never present. Non-positive sizes are ignored.
+func WithMissClassification(size int) Option {
+	return func(c *Cache) {
+		if size <= 0 {
+			return
+		}
+		c.tombs = newGhostList(size)
+	}
+}
+
+// classify counts the miss on the key by the cause of its removal. Nil
+// counters are skipped.
+func (c *Cache) classify(key interface{}, stats ...*Stats) {
+	if c.tombs == nil {
+		return
+	}
+	cause, ok := c.tombs.cause(key)
+	for _, s := range stats {
+		if s == nil {
+			continue
+		}
+		switch {
+		case !ok:
+			s.MissesNeverPresent++
+		case cause == missExpired:
+			s.MissesExpired++
+		case cause == missEvicted:
+			s.MissesEvicted++
+		case cause == missInvalidated:
+			s.MissesInvalidated++
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithMissClassification(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		prepare   func(c *Cache)
+		getKeys   []any
+		wantStats Stats
+	}{
+		{
+			name:      "classifies misses of never added keys",
+			size:      10,
+			getKeys:   []any{k},
+			wantStats: Stats{Misses: 1, MissesNeverPresent: 1},
+		},
+		{
+			name: "classifies misses of expired keys",
+			size: 10,
+			prepare: func(c *Cache) {
+				addItemsWithExp(t, c, [][]any{{k, v, time.Nanosecond}})
+				time.Sleep(time.Millisecond)
+			},
+			getKeys:   []any{k, k},
+			wantStats: Stats{Misses: 2, MissesExpired: 2},
+		},
+		{
+			name: "classifies misses of evicted keys",
+			size: 10,
+			prepare: func(c *Cache) {
+				addItems(t, c, [][]any{{k, v}, {k + k, v}, {k + k + k, v}})
+			},
+			getKeys:   []any{k},
+			wantStats: Stats{Misses: 1, MissesEvicted: 1},
+		},
+		{
+			name: "classifies misses of invalidated keys",
+			size: 10,
+			prepare: func(c *Cache) {
+				addItems(t, c, [][]any{{k, v}})
+				c.BumpNamespace("")
+			},
+			getKeys:   []any{k, k},
+			wantStats: Stats{Misses: 2, MissesInvalidated: 2},
+		},
+		{
+			name: "classifies misses of removed keys as invalidated",
+			size: 10,
+			prepare: func(c *Cache) {
+				addItems(t, c, [][]any{{k, v}, {k + k, v}})
+				c.Remove(k)
+				c.RemoveOldest()
+			},
+			getKeys:   []any{k, k + k},
+			wantStats: Stats{Misses: 2, MissesInvalidated: 2},
+		},
+		{
+			name: "forgets keys that are added again",
+			size: 10,
+			prepare: func(c *Cache) {
+				addItems(t, c, [][]any{{k, v}, {k + k, v}, {k + k + k, v}, {k, v}})
+			},
+			getKeys:   []any{k, k + k},
+			wantStats: Stats{Misses: 1, MissesEvicted: 1},
+		},
+		{
+			name: "forgets the oldest removals",
+			size: 1,
+			prepare: func(c *Cache) {
+				addItems(t, c, [][]any{{k, v}, {k + k, v}, {k + k + k, v}, {"a", v}})
+			},
+			getKeys:   []any{k, k + k},
+			wantStats: Stats{Misses: 2, MissesEvicted: 1, MissesNeverPresent: 1},
+		},
+		{
+			name:      "ignores non-positive size",
+			size:      0,
+			prepare:   func(c *Cache) { addItems(t, c, [][]any{{k, v}, {k + k, v}, {k + k + k, v}}) },
+			getKeys:   []any{k},
+			wantStats: Stats{Misses: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(2, WithMissClassification(tt.size))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if tt.prepare != nil {
+				tt.prepare(c)
+			}
+			for _, key := range tt.getKeys {
+				c.Get(key)
+			}
+			s := c.Stats()
+			got := Stats{
+				Misses:             s.Misses,
+				MissesExpired:      s.MissesExpired,
+				MissesEvicted:      s.MissesEvicted,
+				MissesInvalidated:  s.MissesInvalidated,
+				MissesNeverPresent: s.MissesNeverPresent,
+			}
+			if got != tt.wantStats {
+				t.Errorf("unexpected stats, got %+v, want %+v", got, tt.wantStats)
+			}
+		})
+	}
+}
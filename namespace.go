@@ -0,0 +1,53 @@
+package cache
+
+import "strings"
+
+// WithNamespace sets the function that derives the namespace of a key.
+// Namespaces group keys so they can be managed together, e.g. invalidated
+// with BumpNamespace. If it is not set, all keys belong to the "" namespace.
+func WithNamespace(fn func(key interface{}) string) Option {
+	return func(c *Cache) {
+		c.nsFn = fn
+	}
+}
+
+// PrefixNamespace returns a namespace function that can be passed to
+// WithNamespace. The namespace of a string key is the part before the first
+// sep, e.g. "user" for "user:42" when sep is ":". Other keys and keys without
+// sep belong to the "" namespace.
+func PrefixNamespace(sep string) func(key interface{}) string {
+	return func(key interface{}) string {
+		s, ok := key.(string)
+		if !ok {
+			return ""
+		}
+		if i := strings.Index(s, sep); i >= 0 {
+			return s[:i]
+		}
+		return ""
+	}
+}
+
+// BumpNamespace logically invalidates all keys in the given namespace in
+// constant time by incrementing the generation counter of the namespace.
+// Invalidated items are no longer returned and they are removed lazily when
+// they are accessed or evicted. Len counts them until they are removed.
+func (c *Cache) BumpNamespace(ns string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gens[ns]++
+}
+
+// namespace returns the namespace of the key.
+func (c *Cache) namespace(key interface{}) string {
+	if c.nsFn == nil {
+		return ""
+	}
+	return c.nsFn(key)
+}
+
+// stale reports whether the item belongs to an older generation of its
+// namespace.
+func (c *Cache) stale(item Item) bool {
+	return item.gen != c.gens[c.namespace(item.Key)]
+}
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCache_BumpNamespace(t *testing.T) {
+	tests := []struct {
+		name       string
+		nsFn       func(key any) string
+		addPairs   [][]any
+		bump       []string
+		wantFound  map[any]bool
+		wantKeys   []any
+		wantLength int
+	}{
+		{
+			name:       "invalidates only keys in the bumped namespace",
+			nsFn:       PrefixNamespace(":"),
+			addPairs:   [][]any{{"user:1", v}, {"user:2", v}, {"geo:1", v}},
+			bump:       []string{"user"},
+			wantFound:  map[any]bool{"user:1": false, "user:2": false, "geo:1": true},
+			wantKeys:   []any{"geo:1"},
+			wantLength: 1,
+		},
+		{
+			name:       "bumping unknown namespace keeps all keys",
+			nsFn:       PrefixNamespace(":"),
+			addPairs:   [][]any{{"user:1", v}, {"geo:1", v}},
+			bump:       []string{"session"},
+			wantFound:  map[any]bool{"user:1": true, "geo:1": true},
+			wantKeys:   []any{"geo:1", "user:1"},
+			wantLength: 2,
+		},
+		{
+			name:       "all keys are in the empty namespace without namespace function",
+			nsFn:       nil,
+			addPairs:   [][]any{{k, v}, {k + k, v + v}},
+			bump:       []string{""},
+			wantFound:  map[any]bool{k: false, k + k: false},
+			wantKeys:   nil,
+			wantLength: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(5, WithNamespace(tt.nsFn))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			addItems(t, c, tt.addPairs)
+			for _, ns := range tt.bump {
+				c.BumpNamespace(ns)
+			}
+			if keys := c.Keys(); !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("unexpected keys, got %v, want %v", keys, tt.wantKeys)
+			}
+			for key, want := range tt.wantFound {
+				if _, found := c.Get(key); found != want {
+					t.Errorf("cache.Get(%v) found = %v, want %v", key, found, want)
+				}
+			}
+			if c.Len() != tt.wantLength {
+				t.Errorf("unexpected length, got %v, want %v", c.Len(), tt.wantLength)
+			}
+		})
+	}
+}
+
+func TestCache_BumpNamespaceAdd(t *testing.T) {
+	c, err := New(2, WithNamespace(PrefixNamespace(":")))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	addItems(t, c, [][]any{{"user:1", v}})
+	c.BumpNamespace("user")
+	if err := c.Add("user:1", v+v, 0); err != nil {
+		t.Errorf("unexpected error re-adding invalidated key, got %v", err)
+	}
+	if got, _ := c.Get("user:1"); got != v+v {
+		t.Errorf("unexpected value, got %v, want %v", got, v+v)
+	}
+}
+
+func TestPrefixNamespace(t *testing.T) {
+	tests := []struct {
+		name string
+		key  any
+		want string
+	}{
+		{
+			name: "returns prefix before separator",
+			key:  "user:42",
+			want: "user",
+		},
+		{
+			name: "returns empty namespace for key without separator",
+			key:  "user",
+			want: "",
+		},
+		{
+			name: "returns empty namespace for non-string key",
+			key:  42,
+			want: "",
+		},
+	}
+	fn := PrefixNamespace(":")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fn(tt.key); got != tt.want {
+				t.Errorf("unexpected namespace, got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"container/list"
+	"time"
+)
+
+// WithCompactItems keeps the items small by not recording their creation
+// time, last access time and number of hits. Without them, an item takes 48
+// bytes besides its key and value instead of 128, unless other features keep
+// metadata for it. PeekWithInfo, ExportNDJSON and the snapshots written by
+// Save report them as 0, and RecencyHistogram counts only the items with an
+// idle timeout. It is ignored if PolicySampled, WithReadRepair, WithRefresh,
+// WithEvictionAudit or WithLifetimeHistograms is used, since they rely on
+// these fields.
+func WithCompactItems() Option {
+	return func(c *Cache) {
+		c.compactItems = true
+	}
+}
+
+// node is the compact form of an Item held by the elements of the list. The
+// fields of the item which are used only by some features are kept in meta,
+// which is nil for the items that have none of them, see WithCompactItems.
+type node struct {
+	key  interface{}
+	val  interface{}
+	exp  int64
+	meta *itemMeta
+}
+
+// itemMeta keeps the fields of an Item besides its key, value and expiration.
+// Without WithCompactItems, every item has the creation time. Otherwise, only
+// the items using features like WithMaxBytes, SoftTTL or PolicySLRU do.
+type itemMeta struct {
+	created   int64
+	accessed  int64
+	hits      uint64
+	idle      time.Duration
+	cost      int64
+	soft      int64
+	source    string
+	gen       uint64
+	protected bool
+}
+
+// newNode returns the node holding the item.
+func newNode(item Item) *node {
+	n := &node{}
+	n.set(item)
+	return n
+}
+
+// item returns the Item held by the node.
+func (n *node) item() Item {
+	item := Item{Key: n.key, Val: n.val, Expiration: n.exp}
+	if m := n.meta; m != nil {
+		item.Created = m.created
+		item.Accessed = m.accessed
+		item.Hits = m.hits
+		item.IdleTimeout = m.idle
+		item.Cost = m.cost
+		item.SoftExpiration = m.soft
+		item.Source = m.source
+		item.gen = m.gen
+		item.protected = m.protected
+	}
+	return item
+}
+
+// set stores the item in the node. The metadata of the node is reused, so
+// updating an item which has metadata doesn't allocate.
+func (n *node) set(item Item) {
+	n.key = item.Key
+	n.val = item.Val
+	n.exp = item.Expiration
+	m := itemMeta{
+		created:   item.Created,
+		accessed:  item.Accessed,
+		hits:      item.Hits,
+		idle:      item.IdleTimeout,
+		cost:      item.Cost,
+		soft:      item.SoftExpiration,
+		source:    item.Source,
+		gen:       item.gen,
+		protected: item.protected,
+	}
+	switch {
+	case m == itemMeta{}:
+		n.meta = nil
+	case n.meta == nil:
+		n.meta = &m
+	default:
+		*n.meta = m
+	}
+}
+
+// setItem stores the item in the element. The node of the element is
+// replaced if the element is corrupt.
+func setItem(e *list.Element, item Item) {
+	if n, ok := e.Value.(*node); ok && n != nil {
+		n.set(item)
+		return
+	}
+	e.Value = newNode(item)
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestNode_Size(t *testing.T) {
+	if size := unsafe.Sizeof(node{}); size > 48 {
+		t.Errorf("unexpected node size, got %v, want at most %v", size, 48)
+	}
+}
+
+func TestNode_Meta(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		callOpts []CallOption
+		wantMeta bool
+	}{
+		{
+			name:     "keeps metadata by default",
+			wantMeta: true,
+		},
+		{
+			name:     "keeps no metadata for compact items",
+			opts:     []Option{WithCompactItems()},
+			wantMeta: false,
+		},
+		{
+			name:     "keeps metadata of compact item with idle timeout",
+			opts:     []Option{WithCompactItems()},
+			callOpts: []CallOption{ExpireAfterAccess(time.Minute)},
+			wantMeta: true,
+		},
+		{
+			name:     "keeps metadata of compact item with source",
+			opts:     []Option{WithCompactItems()},
+			callOpts: []CallOption{Source("manual")},
+			wantMeta: true,
+		},
+		{
+			name:     "ignores compact items for sampled policy",
+			opts:     []Option{WithCompactItems(), WithEvictionPolicy(PolicySampled)},
+			wantMeta: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(3, tt.opts...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if err := c.Add(k, v, time.Hour, tt.callOpts...); err != nil {
+				t.Fatalf(err.Error())
+			}
+			c.Get(k)
+			n := c.lst.Front().Value.(*node)
+			if (n.meta != nil) != tt.wantMeta {
+				t.Errorf("unexpected metadata, got %+v, want metadata %v", n.meta, tt.wantMeta)
+			}
+			if got := mustItem(c.lst.Front()); got.Key != k || got.Val != v || got.Expiration == 0 {
+				t.Errorf("unexpected item, got %+v", got)
+			}
+		})
+	}
+}
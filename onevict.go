@@ -0,0 +1,40 @@
+package cache
+
+// WithOnEvict sets a callback that is called with the key and the value of
+// every item removed from the cache, so the resources tied to the value can
+// be released. It is called for the items evicted to make room or by Resize,
+// the items deleted with Remove, RemoveOldest or Clear, and the expired or
+// invalidated items removed by Get, Add, ClearExpiredData or Compact. It is
+// not called when a value is overwritten with Replace or UpdateVal. The
+// callback runs after the lock of the cache is released, so it may call the
+// methods of the cache.
+func WithOnEvict(fn func(key, val interface{})) Option {
+	return func(c *Cache) {
+		c.onEvict = fn
+	}
+}
+
+// evicted queues the item for the callback set with WithOnEvict.
+func (c *Cache) evicted(item Item) {
+	if c.onEvict != nil {
+		c.evictedItems = append(c.evictedItems, item)
+	}
+}
+
+// evictLocker is a locker that passes the removed items to the callback set
+// with WithOnEvict after releasing the write lock.
+type evictLocker struct {
+	locker
+	c *Cache
+}
+
+// Unlock releases the write lock and calls the callback for the items
+// removed while it was held.
+func (l *evictLocker) Unlock() {
+	items := l.c.evictedItems
+	l.c.evictedItems = nil
+	l.locker.Unlock()
+	for _, item := range items {
+		l.c.onEvict(item.Key, item.Val)
+	}
+}
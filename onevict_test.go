@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithOnEvict(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		addPairs [][]any
+		op       func(c *Cache)
+		wantKeys []any
+	}{
+		{
+			name:     "calls for capacity evictions",
+			capacity: 1,
+			addPairs: [][]any{{k, v, time.Duration(0)}, {k + k, v, time.Duration(0)}},
+			op:       func(c *Cache) {},
+			wantKeys: []any{k},
+		},
+		{
+			name:     "calls for resize",
+			capacity: 3,
+			addPairs: [][]any{{k, v, time.Duration(0)}, {k + k, v, time.Duration(0)}, {k + k + k, v, time.Duration(0)}},
+			op:       func(c *Cache) { c.Resize(1) },
+			wantKeys: []any{k, k + k},
+		},
+		{
+			name:     "calls for remove",
+			capacity: 3,
+			addPairs: [][]any{{k, v, time.Duration(0)}, {k + k, v, time.Duration(0)}},
+			op:       func(c *Cache) { c.Remove(k + k) },
+			wantKeys: []any{k + k},
+		},
+		{
+			name:     "calls for expiration sweep",
+			capacity: 3,
+			addPairs: [][]any{{k, v, time.Millisecond}, {k + k, v, time.Duration(0)}},
+			op: func(c *Cache) {
+				time.Sleep(10 * time.Millisecond)
+				c.ClearExpiredData()
+			},
+			wantKeys: []any{k},
+		},
+		{
+			name:     "does not call for missing key",
+			capacity: 3,
+			addPairs: [][]any{{k, v, time.Duration(0)}},
+			op:       func(c *Cache) { c.Remove(k + k) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys []any
+			c, err := New(tt.capacity, WithOnEvict(func(key, val any) {
+				keys = append(keys, key)
+			}))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			addItemsWithExp(t, c, tt.addPairs)
+			tt.op(c)
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("unexpected evicted keys, got %v, want %v", keys, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestWithOnEvict_CallsCache(t *testing.T) {
+	var c *Cache
+	c, err := New(1, WithOnEvict(func(key, val any) {
+		// The callback runs without the lock, so it can use the cache.
+		c.Len()
+	}))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	addItems(t, c, [][]any{{k, v}, {k + k, v}})
+}
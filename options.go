@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Option configures optional behaviour of the cache. Options are passed to
+// New.
+type Option func(*Cache)
+
+// WithGetDoesNotPromote makes Get keep the access order of the cache, so bulk
+// reads don't change which items are evicted next. Unlike Peek, Get still
+// removes the items that are invalidated.
+func WithGetDoesNotPromote() Option {
+	return func(c *Cache) {
+		c.noPromote = true
+	}
+}
+
+// WithAdmitFunc sets the function consulted on every Add to decide whether
+// the item should be stored. It allows enforcing rules centrally, e.g. never
+// caching values over a size. Add returns ErrNotAdmitted for items that are
+// not admitted. The function is called without holding the lock of the cache.
+func WithAdmitFunc(fn func(key, val interface{}, exp time.Duration) bool) Option {
+	return func(c *Cache) {
+		c.admit = fn
+	}
+}
+
+// WithValidator sets the function that checks the values written with Add,
+// Replace and UpdateVal. Values for which it returns error are rejected with a
+// *ValidationError wrapping that error, so malformed values are caught when
+// they are written instead of when they are read. The function is called
+// without holding the lock of the cache.
+func WithValidator(fn func(key, val interface{}) error) Option {
+	return func(c *Cache) {
+		c.validator = fn
+	}
+}
+
+// WithExpireAfterAccess sets the idle timeout of the items, so they expire if
+// they are not retrieved with Get for d, in addition to their expiration
+// duration. Peek and Contains don't extend the idle timeout.
+func WithExpireAfterAccess(d time.Duration) Option {
+	return func(c *Cache) {
+		c.idle = d
+	}
+}
+
+// CallOption changes the behaviour of a single Add or Get call. Options that
+// don't apply to the called method are ignored.
+type CallOption func(*callConfig)
+
+// callConfig is the behaviour of a single call, set by CallOptions.
+type callConfig struct {
+	noEvict   bool
+	noPromote bool
+	idle      time.Duration
+	source    string
+	soft      time.Duration
+	cost      int64
+	hasCost   bool
+	bypass    bool
+	noStore   bool
+	ctx       context.Context
+	served    *ServeInfo
+	report    *AddReport
+}
+
+// NoEvictOthers makes Add return ErrCacheFull instead of evicting other items
+// when the cache or the quota of the key's namespace is full, or when the item
+// doesn't fit the limit set with WithMaxBytes or WithMaxCost.
+func NoEvictOthers() CallOption {
+	return func(cfg *callConfig) {
+		cfg.noEvict = true
+	}
+}
+
+// Source tags the item added by Add with the code path which populated it,
+// like "warmup" or "manual", to help debugging stale data. The tag is kept in
+// the Source field of the item and counted by SourceCounts.
+func Source(source string) CallOption {
+	return func(cfg *callConfig) {
+		cfg.source = source
+	}
+}
+
+// NoPromote makes Get keep the access order of the cache, like
+// WithGetDoesNotPromote does for all calls.
+func NoPromote() CallOption {
+	return func(cfg *callConfig) {
+		cfg.noPromote = true
+	}
+}
+
+// ExpireAfterAccess makes the item added by Add expire if it is not retrieved
+// with Get for d, in addition to its expiration duration. Whichever passes
+// first expires the item. It overrides the idle timeout set with
+// WithExpireAfterAccess.
+func ExpireAfterAccess(d time.Duration) CallOption {
+	return func(cfg *callConfig) {
+		cfg.idle = d
+	}
+}
+
+// newCallConfig applies the options to an empty callConfig.
+func newCallConfig(opts []CallOption) callConfig {
+	var cfg callConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
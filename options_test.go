@@ -0,0 +1,325 @@
+package cache
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithGetDoesNotPromote(t *testing.T) {
+	tests := []struct {
+		name              string
+		opts              []Option
+		addPairs          [][]any
+		getKeys           []any
+		wantKeysListOrder []any
+	}{
+		{
+			name:              "get promotes item by default",
+			opts:              nil,
+			addPairs:          [][]any{{k, v}, {k + k, v + v}, {k + k + k, v + v + v}},
+			getKeys:           []any{k},
+			wantKeysListOrder: []any{k, k + k + k, k + k},
+		},
+		{
+			name:              "get keeps order when promotion is disabled",
+			opts:              []Option{WithGetDoesNotPromote()},
+			addPairs:          [][]any{{k, v}, {k + k, v + v}, {k + k + k, v + v + v}},
+			getKeys:           []any{k, k + k},
+			wantKeysListOrder: []any{k + k + k, k + k, k},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(3, tt.opts...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			addItems(t, c, tt.addPairs)
+			for _, key := range tt.getKeys {
+				if _, found := c.Get(key); !found {
+					t.Errorf("expected key %v to be found", key)
+				}
+			}
+			cmpCacheListOrder(t, c, tt.wantKeysListOrder)
+		})
+	}
+}
+
+func TestWithAdmitFunc(t *testing.T) {
+	admit := func(key, val any, exp time.Duration) bool {
+		s, ok := val.(string)
+		return ok && len(s) <= 3 && exp >= 0
+	}
+	tests := []struct {
+		name      string
+		key       any
+		val       any
+		exp       time.Duration
+		wantErr   error
+		wantFound bool
+	}{
+		{
+			name:      "adds admitted item",
+			key:       k,
+			val:       v,
+			exp:       time.Hour,
+			wantErr:   nil,
+			wantFound: true,
+		},
+		{
+			name:      "rejects item by value",
+			key:       k,
+			val:       v + v,
+			exp:       time.Hour,
+			wantErr:   ErrNotAdmitted,
+			wantFound: false,
+		},
+		{
+			name:      "rejects item by expiration",
+			key:       k,
+			val:       v,
+			exp:       -1 * time.Hour,
+			wantErr:   ErrNotAdmitted,
+			wantFound: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(3, WithAdmitFunc(admit))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			err = c.Add(tt.key, tt.val, tt.exp)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error, got %v, want %v", err, tt.wantErr)
+			}
+			if found := c.Contains(tt.key); found != tt.wantFound {
+				t.Errorf("cache.Contains() found = %v, want %v", found, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestWithValidator(t *testing.T) {
+	errNotString := errors.New("value is not a string")
+	validator := func(key, val any) error {
+		if _, ok := val.(string); !ok {
+			return errNotString
+		}
+		return nil
+	}
+	tests := []struct {
+		name    string
+		write   func(c *Cache) error
+		key     any
+		wantErr error
+		wantVal any
+	}{
+		{
+			name:    "adds valid value",
+			write:   func(c *Cache) error { return c.Add(k+k, v, 0) },
+			key:     k + k,
+			wantErr: nil,
+			wantVal: v,
+		},
+		{
+			name:    "rejects invalid value on Add",
+			write:   func(c *Cache) error { return c.Add(k+k, 42, 0) },
+			key:     k + k,
+			wantErr: errNotString,
+			wantVal: nil,
+		},
+		{
+			name:    "rejects invalid value on Replace",
+			write:   func(c *Cache) error { return c.Replace(k, 42) },
+			key:     k,
+			wantErr: errNotString,
+			wantVal: v,
+		},
+		{
+			name: "rejects invalid value on UpdateVal",
+			write: func(c *Cache) error {
+				_, err := c.UpdateVal(k, 42)
+				return err
+			},
+			key:     k,
+			wantErr: errNotString,
+			wantVal: v,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(3, WithValidator(validator))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			addItems(t, c, [][]any{{k, v}})
+			err = tt.write(c)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error, got %v, want %v", err, tt.wantErr)
+			}
+			var verr *ValidationError
+			if tt.wantErr != nil && !errors.As(err, &verr) {
+				t.Errorf("expected *ValidationError, got %T", err)
+			}
+			if got, _ := c.Peek(tt.key); got != tt.wantVal {
+				t.Errorf("unexpected value, got %v, want %v", got, tt.wantVal)
+			}
+		})
+	}
+}
+
+func TestNoEvictOthers(t *testing.T) {
+	tests := []struct {
+		name              string
+		opts              []Option
+		addPairs          [][]any
+		key               any
+		wantErr           error
+		wantKeysListOrder []any
+	}{
+		{
+			name:              "adds item when cache has room",
+			addPairs:          [][]any{{k, v}},
+			key:               k + k,
+			wantErr:           nil,
+			wantKeysListOrder: []any{k + k, k},
+		},
+		{
+			name:              "returns error when cache is full",
+			addPairs:          [][]any{{k, v}, {k + k, v + v}},
+			key:               k + k + k,
+			wantErr:           ErrCacheFull,
+			wantKeysListOrder: []any{k + k, k},
+		},
+		{
+			name:              "returns error when key exists",
+			addPairs:          [][]any{{k, v}, {k + k, v + v}},
+			key:               k,
+			wantErr:           ErrKeyExists,
+			wantKeysListOrder: []any{k + k, k},
+		},
+		{
+			name:              "returns error when namespace quota is full",
+			opts:              []Option{WithNamespace(PrefixNamespace(":")), WithNamespaceQuota("a", 1)},
+			addPairs:          [][]any{{"a:1", v}},
+			key:               "a:2",
+			wantErr:           ErrCacheFull,
+			wantKeysListOrder: []any{"a:1"},
+		},
+		{
+			name:              "returns error when item exceeds byte limit",
+			opts:              []Option{WithMaxBytes(int64(len(v)), nil)},
+			addPairs:          [][]any{{k, v}},
+			key:               k + k,
+			wantErr:           ErrCacheFull,
+			wantKeysListOrder: []any{k},
+		},
+		{
+			name:              "returns error when item exceeds cost limit",
+			opts:              []Option{WithMaxCost(1)},
+			addPairs:          [][]any{{k, v}},
+			key:               k + k,
+			wantErr:           ErrCacheFull,
+			wantKeysListOrder: []any{k},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(2, tt.opts...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			addItems(t, c, tt.addPairs)
+			if err := c.Add(tt.key, v, 0, NoEvictOthers()); err != tt.wantErr {
+				t.Errorf("unexpected error, got %v, want %v", err, tt.wantErr)
+			}
+			cmpCacheListOrder(t, c, tt.wantKeysListOrder)
+		})
+	}
+}
+
+func TestNoPromote(t *testing.T) {
+	c := createCache(t, 3)
+	addItems(t, c, [][]any{{k, v}, {k + k, v + v}, {k + k + k, v + v + v}})
+	if _, found := c.Get(k, NoPromote()); !found {
+		t.Errorf("expected key %v to be found", k)
+	}
+	cmpCacheListOrder(t, c, []any{k + k + k, k + k, k})
+	if _, found := c.Get(k); !found {
+		t.Errorf("expected key %v to be found", k)
+	}
+	cmpCacheListOrder(t, c, []any{k, k + k + k, k + k})
+}
+
+func TestExpireAfterAccess(t *testing.T) {
+	const idle = 100 * time.Millisecond
+	tests := []struct {
+		name     string
+		opts     []Option
+		callOpts []CallOption
+		wantIdle time.Duration
+	}{
+		{
+			name:     "uses idle timeout of cache",
+			opts:     []Option{WithExpireAfterAccess(idle)},
+			wantIdle: idle,
+		},
+		{
+			name:     "per-call idle timeout overrides cache",
+			opts:     []Option{WithExpireAfterAccess(time.Hour)},
+			callOpts: []CallOption{ExpireAfterAccess(idle)},
+			wantIdle: idle,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(1, tt.opts...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if err := c.Add(k, v, time.Hour, tt.callOpts...); err != nil {
+				t.Fatalf(err.Error())
+			}
+			if item, _ := c.PeekWithInfo(k); item.IdleTimeout != tt.wantIdle {
+				t.Errorf("unexpected idle timeout, got %v, want %v", item.IdleTimeout, tt.wantIdle)
+			}
+
+			// Get extends the idle timeout, so the item outlives it.
+			time.Sleep(idle * 6 / 10)
+			if _, found := c.Get(k); !found {
+				t.Fatalf("expected key %v to be found", k)
+			}
+			time.Sleep(idle * 6 / 10)
+			if _, found := c.Get(k); !found {
+				t.Fatalf("expected key %v to be found after get", k)
+			}
+			time.Sleep(idle * 3 / 2)
+			if _, found := c.Get(k); found {
+				t.Errorf("expected key %v to expire after idle timeout", k)
+			}
+		})
+	}
+}
+
+func TestSource(t *testing.T) {
+	c := createCache(t, 5)
+	for _, key := range []any{k, k + k} {
+		if err := c.Add(key, v, 0, Source("warmup")); err != nil {
+			t.Fatalf("unexpected error, got %v", err)
+		}
+	}
+	addItems(t, c, [][]any{{k + k + k, v}})
+	if _, err := c.GetOrLoad("loaded", 0, func(key any) (any, error) { return v, nil }); err != nil {
+		t.Fatalf("unexpected error, got %v", err)
+	}
+	if item, _ := c.PeekWithInfo(k); item.Source != "warmup" {
+		t.Errorf("unexpected source, got %q, want %q", item.Source, "warmup")
+	}
+	want := map[string]int{"warmup": 2, "": 1, "loader": 1}
+	if got := c.SourceCounts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected source counts, got %v, want %v", got, want)
+	}
+}
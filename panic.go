@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"container/list"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is passed to the handler set with WithPanicHandler when a
+// function called by the cache panics.
+type PanicError struct {
+	// Op is the operation which panicked, like "janitor" or "load".
+	Op string
+
+	// Value is the value passed to panic.
+	Value interface{}
+
+	// Stack is the stack trace of the goroutine when it panicked.
+	Stack []byte
+}
+
+// Error returns the error message.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic in %s: %v", e.Op, e.Value)
+}
+
+// Unwrap returns the value passed to panic if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// WithPanicHandler recovers the panics of the functions the cache calls on
+// behalf of the caller, such as the loaders of GetOrLoad, the read repair
+// and refresh functions and the callbacks called by the janitor, the
+// scheduled jobs and the configuration watcher, and passes them to fn.
+// Background goroutines keep running after a panic, GetOrLoad returns the
+// *PanicError and the item is not repaired by Get. Without a handler, panics
+// are not recovered.
+func WithPanicHandler(fn func(*PanicError)) Option {
+	return func(c *Cache) {
+		c.panicFn = fn
+	}
+}
+
+// guard calls fn and recovers its panic if a panic handler is set. The
+// recovered panic is passed to the handler and returned.
+func (c *Cache) guard(op string, fn func()) (perr *PanicError) {
+	if c.panicFn == nil {
+		fn()
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			perr = &PanicError{Op: op, Value: r, Stack: debug.Stack()}
+			c.panicFn(perr)
+		}
+	}()
+	fn()
+	return nil
+}
+
+// itemOf returns the item held by the element, or a *CorruptError if the
+// element holds something else.
+func itemOf(e *list.Element) (Item, error) {
+	n, ok := e.Value.(*node)
+	if !ok || n == nil {
+		return Item{}, &CorruptError{Value: e.Value}
+	}
+	return n.item(), nil
+}
+
+// mustItem is itemOf for the code which can't return error. It panics with
+// the *CorruptError, which the methods returning error turn back into an
+// error with recoverCorrupt.
+func mustItem(e *list.Element) Item {
+	item, err := itemOf(e)
+	if err != nil {
+		panic(err)
+	}
+	return item
+}
+
+// recoverCorrupt recovers a panic raised by mustItem and sets *err to its
+// *CorruptError. Other panics are not recovered. It must be deferred before
+// the lock of the cache, so the lock is released first.
+func recoverCorrupt(err *error) {
+	if r := recover(); r != nil {
+		cerr, ok := r.(*CorruptError)
+		if !ok {
+			panic(r)
+		}
+		*err = cerr
+	}
+}
@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithPanicHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		op     func(t *testing.T, c *Cache)
+		wantOp string
+	}{
+		{
+			name: "returns loader panic as error",
+			op: func(t *testing.T, c *Cache) {
+				_, err := c.GetOrLoad(k, 0, func(key any) (any, error) { panic("boom") })
+				var perr *PanicError
+				if !errors.As(err, &perr) {
+					t.Errorf("unexpected error, got %v, want *PanicError", err)
+				}
+			},
+			wantOp: "load",
+		},
+		{
+			name: "reports item as not found if repair panics",
+			opts: []Option{WithReadRepair(func(stale Item) (any, time.Duration, bool) { panic("boom") })},
+			op: func(t *testing.T, c *Cache) {
+				addItemsWithExp(t, c, [][]any{{k, v, -time.Hour}})
+				if _, found := c.Get(k); found {
+					t.Errorf("expected item to be reported as not found")
+				}
+			},
+			wantOp: "repair",
+		},
+		{
+			name: "keeps janitor running if callback panics",
+			opts: []Option{
+				WithJanitor(10 * time.Millisecond),
+				WithOnEvict(func(key, val any) { panic("boom") }),
+			},
+			op: func(t *testing.T, c *Cache) {
+				addItemsWithExp(t, c, [][]any{{k, v, time.Millisecond}})
+				time.Sleep(50 * time.Millisecond)
+				addItemsWithExp(t, c, [][]any{{k, v, time.Millisecond}})
+				time.Sleep(50 * time.Millisecond)
+				if c.Len() != 0 {
+					t.Errorf("expected janitor to remove expired items, got length %v", c.Len())
+				}
+			},
+			wantOp: "janitor",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var ops []string
+			opts := append(tt.opts, WithPanicHandler(func(err *PanicError) {
+				mu.Lock()
+				defer mu.Unlock()
+				ops = append(ops, err.Op)
+			}))
+			c, err := New(3, opts...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			defer c.Close()
+			tt.op(t, c)
+			mu.Lock()
+			defer mu.Unlock()
+			if len(ops) == 0 || ops[0] != tt.wantOp {
+				t.Errorf("unexpected recovered ops, got %v, want %v", ops, tt.wantOp)
+			}
+		})
+	}
+}
+
+func TestCache_PanicWithoutHandler(t *testing.T) {
+	c := createCache(t, 3)
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected panic without handler")
+		}
+	}()
+	c.GetOrLoad(k, 0, func(key any) (any, error) { panic("boom") })
+}
+
+func TestCorruptError(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(c *Cache) error
+	}{
+		{
+			name: "Add returns error",
+			call: func(c *Cache) error { return c.Add(k+k, v, 0) },
+		},
+		{
+			name: "Remove returns error",
+			call: func(c *Cache) error { return c.Remove(k + k) },
+		},
+		{
+			name: "Replace returns error",
+			call: func(c *Cache) error { return c.Replace(k+k, v) },
+		},
+		{
+			name: "UpdateVal returns error",
+			call: func(c *Cache) error {
+				_, err := c.UpdateVal(k+k, v)
+				return err
+			},
+		},
+		{
+			name: "GetOrLoad returns error",
+			call: func(c *Cache) error {
+				_, err := c.GetOrLoad(k+k, 0, func(key any) (any, error) { return v, nil })
+				return err
+			},
+		},
+		{
+			name: "Healthy returns error",
+			call: func(c *Cache) error { return c.Healthy() },
+		},
+		{
+			name: "Save returns error",
+			call: func(c *Cache) error { return c.Save(io.Discard) },
+		},
+		{
+			name: "Get panics with error",
+			call: func(c *Cache) (err error) {
+				defer func() { err, _ = recover().(error) }()
+				c.Get(k + k)
+				return nil
+			},
+		},
+		{
+			name: "Range panics with error",
+			call: func(c *Cache) (err error) {
+				defer func() { err, _ = recover().(error) }()
+				c.Range(func(key, val any) bool { return true })
+				return nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := createCache(t, 3)
+			addItems(t, c, [][]any{{k, v}})
+			c.lst.PushBack("junk")
+			c.len++
+			err := tt.call(c)
+			var cerr *CorruptError
+			if !errors.As(err, &cerr) || cerr.Value != "junk" {
+				t.Errorf("unexpected error, got %v, want *CorruptError", err)
+			}
+			// The lock is released, so the cache can still be used.
+			c.Len()
+		})
+	}
+}
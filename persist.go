@@ -0,0 +1,230 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// snapshotMagic identifies snapshots written by Save.
+var snapshotMagic = [4]byte{'L', 'R', 'U', 'C'}
+
+// snapshotVersion is the version of the snapshot format written by Save.
+const snapshotVersion = 1
+
+// maxRecordSize is the largest record Load accepts. A bigger length means the
+// length itself is corrupt.
+const maxRecordSize = 1 << 30
+
+// record is the persisted form of an item.
+type record struct {
+	Key        interface{}
+	Val        interface{}
+	Expiration int64
+	Created    int64
+	Accessed   int64
+	Hits       uint64
+
+	// IdleTimeout is decoded as 0 from the records written before it was
+	// added, so the snapshot version doesn't change.
+	IdleTimeout time.Duration
+
+	// Source is decoded as "" from the records written before it was
+	// added.
+	Source string
+
+	// SoftExpiration is decoded as 0 from the records written before it was
+	// added.
+	SoftExpiration int64
+
+	// Cost is decoded as 0 from the records written before it was added.
+	Cost int64
+}
+
+// RestoreReport summarizes the result of Load.
+type RestoreReport struct {
+	// Loaded is the number of items added to cache.
+	Loaded int
+
+	// SkippedExpired is the number of items skipped since they are expired.
+	SkippedExpired int
+
+	// SkippedExisting is the number of items skipped since their key already
+	// exists in cache.
+	SkippedExisting int
+
+	// SkippedCorrupt is the number of records skipped since they are corrupt.
+	SkippedCorrupt int
+}
+
+// Save writes all items in cache to w, including their keys, values,
+// expiration and access metadata. The snapshot keeps the access order of the
+// cache, so loading it restores the order. Keys and values are encoded with
+// encoding/gob, so their concrete types other than the basic types must be
+// registered with gob.Register. Expired and invalidated items are not saved.
+// The snapshot is transformed by the codecs set with WithSnapshotCodecs. It
+// works on a snapshot of the cache and does not change frequency of the item
+// access.
+func (c *Cache) Save(w io.Writer) (err error) {
+	defer recoverCorrupt(&err)
+	// Items are written from the least recently used one, so that adding
+	// them in order restores the access order.
+	items := c.snapshot(true, func(item Item) bool { return !item.Expired() })
+
+	enc, err := encodeWriter(w, c.codecs)
+	if err != nil {
+		return err
+	}
+	if err := writeSnapshot(enc, items); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// writeSnapshot writes the header and the records of the items to w.
+func writeSnapshot(w io.Writer, items []Item) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(snapshotMagic[:]); err != nil {
+		return err
+	}
+	if err := bw.WriteByte(snapshotVersion); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, item := range items {
+		buf.Reset()
+		rec := record{
+			Key:            item.Key,
+			Val:            item.Val,
+			Expiration:     item.Expiration,
+			Created:        item.Created,
+			Accessed:       item.Accessed,
+			Hits:           item.Hits,
+			IdleTimeout:    item.IdleTimeout,
+			Source:         item.Source,
+			SoftExpiration: item.SoftExpiration,
+			Cost:           item.Cost,
+		}
+		if err := gob.NewEncoder(&buf).Encode(&rec); err != nil {
+			return fmt.Errorf("encode key %v: %w", item.Key, err)
+		}
+		var header [8]byte
+		binary.BigEndian.PutUint32(header[:4], uint32(buf.Len()))
+		binary.BigEndian.PutUint32(header[4:], crc32.ChecksumIEEE(buf.Bytes()))
+		if _, err := bw.Write(header[:]); err != nil {
+			return err
+		}
+		if _, err := bw.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Load adds the items of a snapshot written by Save to cache. The snapshot is
+// decoded with the codecs set with WithSnapshotCodecs. It returns error if
+// the snapshot can't be decoded or its header or format version is invalid.
+// Corrupt records are skipped instead of failing the whole load, as well as
+// expired items and items whose key already exists in cache. The returned
+// report counts the loaded and skipped items. If the snapshot holds more
+// items than the capacity, the least recently used ones are evicted as usual.
+func (c *Cache) Load(r io.Reader) (report RestoreReport, err error) {
+	defer recoverCorrupt(&err)
+	dec, err := decodeReader(r, c.codecs)
+	if err != nil {
+		return report, err
+	}
+	br := bufio.NewReader(dec)
+	var header [5]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return report, fmt.Errorf("%w: %v", errInvalidSnapshot, err)
+	}
+	if !bytes.Equal(header[:4], snapshotMagic[:]) {
+		return report, errInvalidSnapshot
+	}
+	if header[4] != snapshotVersion {
+		return report, fmt.Errorf("%w: %d", errSnapshotVersion, header[4])
+	}
+
+	var items []Item
+	for {
+		rec, err := readRecord(br)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, errCorruptRecord) {
+			report.SkippedCorrupt++
+			continue
+		}
+		if err != nil {
+			// The record boundaries are lost, the rest can't be read.
+			report.SkippedCorrupt++
+			break
+		}
+		items = append(items, Item{
+			Key:            rec.Key,
+			Val:            rec.Val,
+			Expiration:     rec.Expiration,
+			Created:        rec.Created,
+			Accessed:       rec.Accessed,
+			Hits:           rec.Hits,
+			IdleTimeout:    rec.IdleTimeout,
+			Source:         rec.Source,
+			SoftExpiration: rec.SoftExpiration,
+			Cost:           rec.Cost,
+		})
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, item := range items {
+		if item.Expired() {
+			report.SkippedExpired++
+			continue
+		}
+		if _, found := c.get(item.Key); found {
+			report.SkippedExisting++
+			continue
+		}
+		item.gen = c.gens[c.namespace(item.Key)]
+		if c.put(item) == nil {
+			report.Loaded++
+		}
+	}
+	return report, nil
+}
+
+// readRecord reads the next record. It returns io.EOF if there are no more
+// records and errCorruptRecord if the record is corrupt but the next one can
+// still be read.
+func readRecord(r io.Reader) (record, error) {
+	var rec record
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return rec, err
+	}
+	size := binary.BigEndian.Uint32(header[:4])
+	if size > maxRecordSize {
+		return rec, fmt.Errorf("record size %d is too big", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return rec, err
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+		return rec, errCorruptRecord
+	}
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&rec); err != nil {
+		return rec, errCorruptRecord
+	}
+	return rec, nil
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// SaveFile writes a snapshot of the cache to the file at path, see Save. The
+// snapshot is written to a temporary file in the same directory, synced to
+// disk and renamed to path, so a crash while saving never leaves a partially
+// written file at path.
+//
+// keep is the number of snapshots to retain, including the new one. The
+// previous snapshots are rotated to path.1, path.2 and so on, path.1 being
+// the most recent one. If keep is less than 2, only the new snapshot is kept.
+func (c *Cache) SaveFile(path string, keep int) error {
+	return writeFileAtomic(path, c.Save, func() error {
+		return rotateSnapshots(path, keep)
+	})
+}
+
+// LoadFile adds the items of the snapshot file at path to cache, see Load.
+func (c *Cache) LoadFile(path string) (RestoreReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return RestoreReport{}, err
+	}
+	defer f.Close()
+	return c.Load(f)
+}
+
+// writeFileAtomic calls write with a temporary file in the directory of path,
+// syncs it to disk and renames it to path. before is called right before the
+// rename if it is not nil.
+func writeFileAtomic(path string, write func(w io.Writer) error, before func() error) error {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, base+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if before != nil {
+		if err := before(); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// rotateSnapshots shifts the retained snapshots of path by one, dropping the
+// oldest one so that keep snapshots remain after the new one is written. The
+// current snapshot is hard linked instead of renamed where possible, so path
+// keeps existing until the new snapshot replaces it.
+func rotateSnapshots(path string, keep int) error {
+	if keep < 2 {
+		return nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	oldest := rotatedName(path, keep-1)
+	if err := os.Remove(oldest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := keep - 2; i >= 1; i-- {
+		err := os.Rename(rotatedName(path, i), rotatedName(path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Link(path, rotatedName(path, 1)); err != nil {
+		return os.Rename(path, rotatedName(path, 1))
+	}
+	return nil
+}
+
+// rotatedName returns the file name of the i-th previous snapshot of path.
+func rotatedName(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+// syncDir syncs the directory so the renames in it are durable. Errors are
+// ignored since directories can't be synced on every platform.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	_ = d.Sync()
+}
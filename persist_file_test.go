@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCache_SaveFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		keep      int
+		saves     int
+		wantFiles []string
+	}{
+		{
+			name:      "keeps only the latest snapshot when keep < 2",
+			keep:      1,
+			saves:     3,
+			wantFiles: []string{"cache.snap"},
+		},
+		{
+			name:      "rotates previous snapshots",
+			keep:      3,
+			saves:     2,
+			wantFiles: []string{"cache.snap", "cache.snap.1"},
+		},
+		{
+			name:      "drops snapshots beyond retention",
+			keep:      3,
+			saves:     5,
+			wantFiles: []string{"cache.snap", "cache.snap.1", "cache.snap.2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "cache.snap")
+			c := createCache(t, 10)
+			for i := 0; i < tt.saves; i++ {
+				if err := c.Add(i, v, 0); err != nil {
+					t.Fatalf(err.Error())
+				}
+				if err := c.SaveFile(path, tt.keep); err != nil {
+					t.Fatalf("unexpected error, got %v", err)
+				}
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Name())
+			}
+			if len(got) != len(tt.wantFiles) {
+				t.Fatalf("unexpected files, got %v, want %v", got, tt.wantFiles)
+			}
+			for i := range got {
+				if got[i] != tt.wantFiles[i] {
+					t.Errorf("unexpected files, got %v, want %v", got, tt.wantFiles)
+				}
+			}
+
+			// Each rotated snapshot holds one item less than the newer one.
+			for i, name := range tt.wantFiles {
+				restored := createCache(t, 10)
+				report, err := restored.LoadFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Fatalf("unexpected error, got %v", err)
+				}
+				if want := tt.saves - i; report.Loaded != want {
+					t.Errorf("unexpected loaded count of %s, got %v, want %v", name, report.Loaded, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCache_LoadFileNotExist(t *testing.T) {
+	c := createCache(t, 1)
+	if _, err := c.LoadFile(filepath.Join(t.TempDir(), "missing")); !os.IsNotExist(err) {
+		t.Errorf("expected not exist error, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCache_SaveLoad(t *testing.T) {
+	tests := []struct {
+		name              string
+		addPairs          [][]any
+		getKeys           []any
+		existingPairs     [][]any
+		loadCapacity      int
+		wantReport        RestoreReport
+		wantKeysListOrder []any
+	}{
+		{
+			name:              "restores empty cache",
+			addPairs:          [][]any{},
+			loadCapacity:      3,
+			wantReport:        RestoreReport{},
+			wantKeysListOrder: []any{},
+		},
+		{
+			name:              "restores items with access order",
+			addPairs:          [][]any{{k, v, time.Duration(0)}, {k + k, v + v, time.Hour}, {k + k + k, v + v + v, time.Duration(0)}},
+			getKeys:           []any{k},
+			loadCapacity:      3,
+			wantReport:        RestoreReport{Loaded: 3},
+			wantKeysListOrder: []any{k, k + k + k, k + k},
+		},
+		{
+			name:              "skips existing keys",
+			addPairs:          [][]any{{k, v, time.Duration(0)}, {k + k, v + v, time.Duration(0)}},
+			existingPairs:     [][]any{{k, v + v + v, time.Duration(0)}},
+			loadCapacity:      3,
+			wantReport:        RestoreReport{Loaded: 1, SkippedExisting: 1},
+			wantKeysListOrder: []any{k + k, k},
+		},
+		{
+			name:              "evicts least recently used items when capacity is smaller",
+			addPairs:          [][]any{{k, v, time.Duration(0)}, {k + k, v + v, time.Duration(0)}, {k + k + k, v + v + v, time.Duration(0)}},
+			loadCapacity:      2,
+			wantReport:        RestoreReport{Loaded: 3},
+			wantKeysListOrder: []any{k + k + k, k + k},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := createCache(t, 3)
+			addItemsWithExp(t, c, tt.addPairs)
+			for _, key := range tt.getKeys {
+				c.Get(key)
+			}
+			var buf bytes.Buffer
+			if err := c.Save(&buf); err != nil {
+				t.Fatalf("unexpected error, got %v", err)
+			}
+
+			restored := createCache(t, tt.loadCapacity)
+			addItemsWithExp(t, restored, tt.existingPairs)
+			report, err := restored.Load(&buf)
+			if err != nil {
+				t.Fatalf("unexpected error, got %v", err)
+			}
+			if report != tt.wantReport {
+				t.Errorf("unexpected report, got %+v, want %+v", report, tt.wantReport)
+			}
+			cmpCacheListOrder(t, restored, tt.wantKeysListOrder)
+			existing := make(map[any]bool)
+			for _, pair := range tt.existingPairs {
+				existing[pair[0]] = true
+			}
+			for _, key := range restored.Keys() {
+				if existing[key] {
+					continue
+				}
+				want, _ := c.PeekWithInfo(key)
+				got, _ := restored.PeekWithInfo(key)
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("unexpected restored item, got %+v, want %+v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCache_LoadCorrupt(t *testing.T) {
+	c := createCache(t, 3)
+	addItemsWithExp(t, c, [][]any{{k, v, time.Duration(0)}, {k + k, v + v, time.Duration(0)}, {k + k + k, v + v + v, time.Duration(0)}})
+	var buf bytes.Buffer
+	if err := c.Save(&buf); err != nil {
+		t.Fatalf("unexpected error, got %v", err)
+	}
+	snapshot := buf.Bytes()
+	// The first record starts after the 5 byte header and its 8 byte record
+	// header. Flipping a payload byte breaks its checksum.
+	corrupted := append([]byte(nil), snapshot...)
+	corrupted[5+8] ^= 0xff
+
+	tests := []struct {
+		name       string
+		snapshot   []byte
+		wantErr    error
+		wantReport RestoreReport
+	}{
+		{
+			name:     "returns error for invalid header",
+			snapshot: []byte("not a snapshot"),
+			wantErr:  errInvalidSnapshot,
+		},
+		{
+			name:     "returns error for truncated header",
+			snapshot: snapshot[:3],
+			wantErr:  errInvalidSnapshot,
+		},
+		{
+			name:     "returns error for unsupported version",
+			snapshot: append(append([]byte(nil), snapshot[:4]...), snapshotVersion+1),
+			wantErr:  errSnapshotVersion,
+		},
+		{
+			name:       "skips record with wrong checksum",
+			snapshot:   corrupted,
+			wantReport: RestoreReport{Loaded: 2, SkippedCorrupt: 1},
+		},
+		{
+			name:       "skips truncated record",
+			snapshot:   snapshot[:len(snapshot)-3],
+			wantReport: RestoreReport{Loaded: 2, SkippedCorrupt: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restored := createCache(t, 3)
+			report, err := restored.Load(bytes.NewReader(tt.snapshot))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error, got %v, want %v", err, tt.wantErr)
+			}
+			if report != tt.wantReport {
+				t.Errorf("unexpected report, got %+v, want %+v", report, tt.wantReport)
+			}
+		})
+	}
+}
+
+func TestCache_LoadExpired(t *testing.T) {
+	c := createCache(t, 3)
+	addItemsWithExp(t, c, [][]any{{k, v, 50 * time.Millisecond}, {k + k, v + v, time.Hour}})
+	var buf bytes.Buffer
+	if err := c.Save(&buf); err != nil {
+		t.Fatalf("unexpected error, got %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	restored := createCache(t, 3)
+	report, err := restored.Load(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error, got %v", err)
+	}
+	want := RestoreReport{Loaded: 1, SkippedExpired: 1}
+	if report != want {
+		t.Errorf("unexpected report, got %+v, want %+v", report, want)
+	}
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"container/list"
+	"math/rand"
+)
+
+// EvictionPolicy selects which item is evicted when the cache is full.
+type EvictionPolicy int
+
+const (
+	// PolicyLRU evicts the least recently used item. Get, UpdateVal and
+	// UpdateExpirationDate move the item to the front of the list. It is the
+	// default policy.
+	PolicyLRU EvictionPolicy = iota
+
+	// PolicyFIFO evicts the item added first. Get, UpdateVal and
+	// UpdateExpirationDate don't change the order of the items, which saves
+	// re-linking the list when recency doesn't predict reuse.
+	PolicyFIFO
+
+	// PolicySampled evicts the least recently used item among a few items
+	// picked at random, like Redis does. Get doesn't change the order of the
+	// items, which trades a small loss of hit rate for cheaper reads. The
+	// number of sampled items is set with WithEvictionSamples.
+	PolicySampled
+
+	// PolicySLRU is the segmented LRU policy. New items enter the
+	// probationary segment and move to the protected segment when they are
+	// retrieved with Get again. The least recently used probationary item is
+	// evicted, so a scan of items retrieved once doesn't flush the items
+	// retrieved repeatedly. When the protected segment is full, its least
+	// recently used item goes back to the probationary segment. The size of
+	// the protected segment is set with WithProtectedRatio.
+	PolicySLRU
+)
+
+// defaultProtectedRatio is the share of the capacity kept for the protected
+// segment of PolicySLRU if WithProtectedRatio is not used.
+const defaultProtectedRatio = 0.8
+
+// defaultEvictionSamples is the number of items sampled by PolicySampled if
+// WithEvictionSamples is not used.
+const defaultEvictionSamples = 5
+
+// WithEvictionPolicy sets the eviction policy of the cache. See
+// EvictionPolicy for the available policies.
+func WithEvictionPolicy(policy EvictionPolicy) Option {
+	return func(c *Cache) {
+		c.policy = policy
+	}
+}
+
+// WithEvictionSamples sets the number of items sampled to pick the item to
+// evict with PolicySampled. More samples approximate LRU better but make
+// evictions slower. Values less than 1 are ignored.
+func WithEvictionSamples(n int) Option {
+	return func(c *Cache) {
+		if n > 0 {
+			c.samples = n
+		}
+	}
+}
+
+// WithProtectedRatio sets the share of the capacity kept for the protected
+// segment of PolicySLRU, the rest is the probationary segment. Ratios which
+// are not between 0 and 1 are ignored.
+func WithProtectedRatio(ratio float64) Option {
+	return func(c *Cache) {
+		if ratio > 0 && ratio < 1 {
+			c.protectedRatio = ratio
+		}
+	}
+}
+
+// reorders reports whether the eviction policy moves the retrieved and
+// updated items to the front of the list.
+func (c *Cache) reorders() bool {
+	return c.policy == PolicyLRU || c.policy == PolicySLRU
+}
+
+// protect moves the probationary item of the element to the protected
+// segment of PolicySLRU. If the protected segment is full, its least recently
+// used item goes back to the front of the probationary segment.
+func (c *Cache) protect(e *list.Element) {
+	item := mustItem(e)
+	if c.policy != PolicySLRU || item.protected {
+		return
+	}
+	item.protected = true
+	setItem(e, item)
+	c.protectedLen++
+
+	ratio := c.protectedRatio
+	if ratio == 0 {
+		ratio = defaultProtectedRatio
+	}
+	if c.protectedLen <= int(ratio*float64(c.cap)) {
+		return
+	}
+	for d := c.lst.Back(); d != nil; d = d.Prev() {
+		if demoted := mustItem(d); demoted.protected && d != e {
+			demoted.protected = false
+			setItem(d, demoted)
+			c.protectedLen--
+			// The front of the list is the most recently used position of
+			// both segments.
+			c.lst.MoveToFront(d)
+			return
+		}
+	}
+}
+
+// victim returns the element to evict to make room for a new item, never
+// keep, which may be nil. It returns nil if there is no other element.
+func (c *Cache) victim(keep *list.Element) *list.Element {
+	switch c.policy {
+	case PolicySampled:
+		if e := c.sampledVictim(keep); e != nil {
+			return e
+		}
+	case PolicySLRU:
+		for e := c.lst.Back(); e != nil; e = e.Prev() {
+			if e != keep && !mustItem(e).protected {
+				return e
+			}
+		}
+	}
+	for e := c.lst.Back(); e != nil; e = e.Prev() {
+		if e != keep {
+			return e
+		}
+	}
+	return nil
+}
+
+// sampledVictim returns the least recently used element among the sampled
+// ones other than keep, or nil if no other element is sampled.
+func (c *Cache) sampledVictim(keep *list.Element) *list.Element {
+	n := c.samples
+	if n == 0 {
+		n = defaultEvictionSamples
+	}
+	// Sampled positions may repeat, like the sampling of Redis. If the cache
+	// holds no more items than the samples, all of them are considered.
+	sampled := make(map[int]bool, n)
+	for i := 0; i < n && c.lst.Len() > n; i++ {
+		sampled[rand.Intn(c.lst.Len())] = true
+	}
+	var victim *list.Element
+	i := 0
+	for e := c.lst.Front(); e != nil; e, i = e.Next(), i+1 {
+		if e == keep || len(sampled) > 0 && !sampled[i] {
+			continue
+		}
+		if victim == nil || mustItem(e).Accessed < mustItem(victim).Accessed {
+			victim = e
+		}
+	}
+	return victim
+}
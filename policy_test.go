@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithEvictionPolicy(t *testing.T) {
+	tests := []struct {
+		name              string
+		policy            EvictionPolicy
+		wantKeysListOrder []any
+	}{
+		{
+			name:              "lru evicts least recently used item",
+			policy:            PolicyLRU,
+			wantKeysListOrder: []any{k + k + k + k, k + k, k},
+		},
+		{
+			name:              "fifo evicts first added item",
+			policy:            PolicyFIFO,
+			wantKeysListOrder: []any{k + k + k + k, k + k + k, k + k},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(3, WithEvictionPolicy(tt.policy))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			addItems(t, c, [][]any{{k, v}, {k + k, v + v}, {k + k + k, v + v + v}})
+			c.Get(k)
+			c.UpdateExpirationDate(k+k, time.Hour)
+			addItems(t, c, [][]any{{k + k + k + k, v}})
+			cmpCacheListOrder(t, c, tt.wantKeysListOrder)
+		})
+	}
+}
+
+func TestWithEvictionSamples(t *testing.T) {
+	tests := []struct {
+		name        string
+		samples     int
+		wantEvicted any
+	}{
+		{
+			name:        "evicts least recently accessed item when all are sampled",
+			samples:     3,
+			wantEvicted: k + k,
+		},
+		{
+			name:        "uses default samples for invalid value",
+			samples:     0,
+			wantEvicted: k + k,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(3, WithEvictionPolicy(PolicySampled), WithEvictionSamples(tt.samples))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			addItems(t, c, [][]any{{k, v}, {k + k, v + v}, {k + k + k, v + v + v}})
+			time.Sleep(time.Millisecond)
+			c.Get(k)
+			c.Get(k + k + k)
+			addItems(t, c, [][]any{{k + k + k + k, v}})
+			// Get doesn't reorder the items.
+			cmpCacheListOrder(t, c, []any{k + k + k + k, k + k + k, k})
+			if c.Contains(tt.wantEvicted) {
+				t.Errorf("expected %v to be evicted", tt.wantEvicted)
+			}
+		})
+	}
+}
+
+func TestCache_VictimSampled(t *testing.T) {
+	c, err := New(100, WithEvictionPolicy(PolicySampled), WithEvictionSamples(1))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	for i := 0; i < 100; i++ {
+		addItems(t, c, [][]any{{i, v}})
+	}
+	// A single sample picks any item, not only the least recently used one.
+	victims := make(map[any]bool)
+	for i := 0; i < 50; i++ {
+		victims[mustItem(c.victim(nil)).Key] = true
+	}
+	if len(victims) < 2 {
+		t.Errorf("expected random victims, got %v", victims)
+	}
+}
+
+func TestPolicySLRU(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		wantKeys []any
+	}{
+		{
+			name:     "lru flushes repeatedly retrieved items on scan",
+			opts:     []Option{WithEvictionPolicy(PolicyLRU)},
+			wantKeys: []any{"f", "e", "d", "c"},
+		},
+		{
+			name:     "slru protects repeatedly retrieved items from scan",
+			opts:     []Option{WithEvictionPolicy(PolicySLRU), WithProtectedRatio(0.5)},
+			wantKeys: []any{"f", "e", "a", "b"},
+		},
+		{
+			name:     "slru demotes items when protected segment is full",
+			opts:     []Option{WithEvictionPolicy(PolicySLRU), WithProtectedRatio(0.25)},
+			wantKeys: []any{"f", "e", "d", "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(4, append(tt.opts, WithInvariantChecks())...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			addItems(t, c, [][]any{{"a", v}, {"b", v}})
+			c.Get("b")
+			c.Get("a")
+			addItems(t, c, [][]any{{"c", v}, {"d", v}, {"e", v}, {"f", v}})
+			cmpCacheListOrder(t, c, tt.wantKeys)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// MemoryPressure configures the controller started by WatchMemory.
+type MemoryPressure struct {
+	// High is the memory usage in bytes at or above which the cache is
+	// shrunk. No controller is started if it is 0.
+	High uint64
+
+	// Low is the memory usage in bytes below which the cache is grown back.
+	// It is 90% of High if it is 0.
+	Low uint64
+
+	// Interval is how often the memory usage is checked. It is 1s if it is 0.
+	Interval time.Duration
+
+	// Factor is the ratio of the capacity kept at each shrinking step, and
+	// its inverse is the ratio of growing steps. It is 0.75 if it is not
+	// between 0 and 1.
+	Factor float64
+
+	// Min is the smallest capacity the cache is shrunk to. It is 1 if it is
+	// less than 1.
+	Min int
+
+	// Gauge returns the memory usage in bytes. It is the HeapAlloc of
+	// runtime.MemStats if it is nil. runtime.ReadMemStats stops the world
+	// briefly, so a cheaper gauge, like the one of a container runtime, is
+	// preferable for short intervals.
+	Gauge func() uint64
+}
+
+// heapAlloc returns the bytes of the allocated heap objects.
+func heapAlloc() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
+// WatchMemory starts a controller which shrinks the cache step by step with
+// Resize while the memory usage is at or above p.High, and grows it back step
+// by step while the usage is below p.Low. The cache is never grown beyond its
+// capacity when WatchMemory is called, so calling Resize while the controller
+// runs doesn't change the capacity it grows back to.
+//
+// A zero p.High is ignored, like a zero limit of WithEmergencyEviction, and
+// the capacity is never changed.
+//
+// It returns a stop function which stops the controller and keeps the current
+// capacity. The capacity is not changed by the controller after stop returns.
+// Calling stop more than once is safe.
+func (c *Cache) WatchMemory(p MemoryPressure) (stop func()) {
+	if p.High == 0 {
+		return func() {}
+	}
+	if p.Low == 0 || p.Low > p.High {
+		p.Low = p.High / 10 * 9
+	}
+	if p.Interval <= 0 {
+		p.Interval = time.Second
+	}
+	if p.Factor <= 0 || p.Factor >= 1 {
+		p.Factor = 0.75
+	}
+	if p.Min < 1 {
+		p.Min = 1
+	}
+	if p.Gauge == nil {
+		p.Gauge = heapAlloc
+	}
+
+	base := c.Cap()
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go c.labeled("memory pressure", func(context.Context) {
+		defer close(stopped)
+		ticker := time.NewTicker(p.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.guard("memory pressure", func() { c.relieve(p, base) })
+			case <-done:
+				return
+			}
+		}
+	})
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
+
+// relieve resizes the cache by one step according to the memory usage.
+func (c *Cache) relieve(p MemoryPressure, base int) {
+	usage := p.Gauge()
+	defer c.resizeChildren()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case usage >= p.High && c.cap > p.Min:
+		size := int(float64(c.cap) * p.Factor)
+		if size < p.Min {
+			size = p.Min
+		}
+		c.resize(size)
+	case usage < p.Low && c.cap < base:
+		size := int(float64(c.cap)/p.Factor) + 1
+		if size > base {
+			size = base
+		}
+		c.resize(size)
+	}
+}
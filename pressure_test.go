@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache_WatchMemory(t *testing.T) {
+	var usage uint64
+	c := createCache(t, 100)
+	for i := 0; i < 100; i++ {
+		if err := c.Add(i, v, 0); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+	stop := c.WatchMemory(MemoryPressure{
+		High:     100,
+		Interval: 5 * time.Millisecond,
+		Factor:   0.5,
+		Min:      10,
+		Gauge:    func() uint64 { return atomic.LoadUint64(&usage) },
+	})
+	defer stop()
+
+	// waitCap waits until the capacity of the cache becomes want.
+	waitCap := func(want int) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for c.Cap() != want && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if got := c.Cap(); got != want {
+			t.Fatalf("unexpected capacity, got %v, want %v", got, want)
+		}
+	}
+
+	atomic.StoreUint64(&usage, 100)
+	waitCap(10)
+	if c.Len() != 10 {
+		t.Errorf("unexpected length, got %v, want %v", c.Len(), 10)
+	}
+	if _, found := c.Peek(99); !found {
+		t.Errorf("expected most recently used key %v to be kept", 99)
+	}
+
+	// Usage between Low and High keeps the capacity.
+	atomic.StoreUint64(&usage, 95)
+	time.Sleep(20 * time.Millisecond)
+	if c.Cap() != 10 {
+		t.Errorf("unexpected capacity, got %v, want %v", c.Cap(), 10)
+	}
+
+	atomic.StoreUint64(&usage, 0)
+	waitCap(100)
+
+	stop()
+	stop()
+	atomic.StoreUint64(&usage, 100)
+	time.Sleep(20 * time.Millisecond)
+	if c.Cap() != 100 {
+		t.Errorf("expected stopped controller to keep capacity, got %v", c.Cap())
+	}
+}
+
+func TestCache_WatchMemoryZeroHigh(t *testing.T) {
+	c := createCache(t, 100)
+	addItems(t, c, [][]any{{k, v}})
+	stop := c.WatchMemory(MemoryPressure{
+		Interval: time.Millisecond,
+		Gauge:    func() uint64 { return 0 },
+	})
+	defer stop()
+	time.Sleep(20 * time.Millisecond)
+	if c.Cap() != 100 {
+		t.Errorf("unexpected capacity, got %v, want %v", c.Cap(), 100)
+	}
+}
@@ -0,0 +1,31 @@
+package cache
+
+// WithNamespaceQuota limits the number of items in the given namespace. When
+// the namespace reaches its quota, adding a new item to it evicts the least
+// recently used item of the same namespace, so a tenant exceeding its quota
+// never evicts items of other tenants. Namespaces are derived from keys by
+// the function set with WithNamespace.
+func WithNamespaceQuota(ns string, max int) Option {
+	return func(c *Cache) {
+		if c.quotas == nil {
+			c.quotas = make(map[string]int)
+		}
+		c.quotas[ns] = max
+	}
+}
+
+// reserveQuota makes room for a new item in the namespace if the namespace
+// has reached its quota, by removing its least recently used items.
+func (c *Cache) reserveQuota(ns string) {
+	max, ok := c.quotas[ns]
+	if !ok {
+		return
+	}
+	for e := c.lst.Back(); e != nil && c.nsLen[ns] >= max; {
+		prev := e.Prev()
+		if c.namespace(mustItem(e).Key) == ns {
+			c.evict(e, EvictQuota)
+		}
+		e = prev
+	}
+}
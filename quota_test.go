@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestWithNamespaceQuota(t *testing.T) {
+	tests := []struct {
+		name              string
+		capacity          int
+		quotas            map[string]int
+		addPairs          [][]any
+		wantKeysListOrder []any
+	}{
+		{
+			name:              "tenant over quota evicts only its own items",
+			capacity:          5,
+			quotas:            map[string]int{"a": 2},
+			addPairs:          [][]any{{"a:1", v}, {"b:1", v}, {"a:2", v}, {"a:3", v}},
+			wantKeysListOrder: []any{"a:3", "a:2", "b:1"},
+		},
+		{
+			name:              "tenant under quota uses regular eviction",
+			capacity:          2,
+			quotas:            map[string]int{"a": 2},
+			addPairs:          [][]any{{"b:1", v}, {"b:2", v}, {"a:1", v}},
+			wantKeysListOrder: []any{"a:1", "b:2"},
+		},
+		{
+			name:              "namespaces without quota are not limited",
+			capacity:          5,
+			quotas:            map[string]int{"a": 1},
+			addPairs:          [][]any{{"b:1", v}, {"b:2", v}, {"b:3", v}},
+			wantKeysListOrder: []any{"b:3", "b:2", "b:1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []Option{WithNamespace(PrefixNamespace(":"))}
+			for ns, max := range tt.quotas {
+				opts = append(opts, WithNamespaceQuota(ns, max))
+			}
+			c, err := New(tt.capacity, opts...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			addItems(t, c, tt.addPairs)
+			cmpCacheListOrder(t, c, tt.wantKeysListOrder)
+			if c.Len() != len(tt.wantKeysListOrder) {
+				t.Errorf("unexpected length, got %v, want %v", c.Len(), len(tt.wantKeysListOrder))
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// SoftTTL makes the item added by Add stale after d, while its expiration
+// duration passed to Add stays the hard limit after which it is never
+// served. Get keeps serving a stale item, and refreshes it in the background
+// if the cache is created with WithRefresh. The soft deadline is kept in the
+// SoftExpiration field of the item.
+func SoftTTL(d time.Duration) CallOption {
+	return func(cfg *callConfig) {
+		cfg.soft = d
+	}
+}
+
+// WithRefresh sets the function which loads a fresh value for the items that
+// are stale since their soft TTL passed, see SoftTTL. When Get finds a stale
+// item, it returns the stale value and calls fn in a new goroutine, unless a
+// refresh of the key is already running. If fn succeeds, the value of the
+// item is replaced and the item counts as added again, so both its soft TTL
+// and its expiration duration start over. If fn returns error, the stale
+// value is kept and the next Get tries again.
+func WithRefresh(fn func(key interface{}) (interface{}, error)) Option {
+	return func(c *Cache) {
+		c.refresh = fn
+	}
+}
+
+// SoftExpired returns true if the soft TTL of the item passed, see SoftTTL.
+// The item may still be served until it is expired.
+func (i Item) SoftExpired() bool {
+	return i.SoftExpiration != 0 && time.Now().UnixNano() > i.SoftExpiration
+}
+
+// startRefresh starts refreshing the stale item unless it is already being
+// refreshed. It must be called while holding the lock of the cache.
+func (c *Cache) startRefresh(stale Item) {
+	if _, ok := c.refreshing[stale.Key]; ok {
+		return
+	}
+	if c.refreshing == nil {
+		c.refreshing = make(map[interface{}]struct{})
+	}
+	c.refreshing[stale.Key] = struct{}{}
+	go c.labeled("refresh", func(context.Context) {
+		var val interface{}
+		var err error
+		if c.guard("refresh", func() { val, err = c.refresh(stale.Key) }) != nil {
+			err = errLoadPanicked
+		}
+		if err == nil {
+			val, err = c.encodeVal(val)
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.refreshing, stale.Key)
+		e, found := c.get(stale.Key)
+		// The item is left alone if it is replaced while refreshing.
+		if err != nil || !found || mustItem(e).Created != stale.Created {
+			return
+		}
+		item, err := c.setVal(e, val)
+		if err != nil {
+			return
+		}
+		now := time.Now().UnixNano()
+		if item.Expiration != 0 {
+			item.Expiration = now + item.Expiration - item.Created
+		}
+		item.SoftExpiration = now + item.SoftExpiration - item.Created
+		item.Created = now
+		setItem(e, item)
+	})
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithRefresh(t *testing.T) {
+	errRefresh := errors.New("refresh failed")
+	tests := []struct {
+		name       string
+		refreshVal any
+		refreshErr error
+		wantServed any
+		wantVal    any
+	}{
+		{
+			name:       "serves stale value and refreshes it",
+			refreshVal: v + v,
+			wantServed: v,
+			wantVal:    v + v,
+		},
+		{
+			name:       "keeps stale value if refresh fails",
+			refreshErr: errRefresh,
+			wantServed: v,
+			wantVal:    v,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			done := make(chan struct{}, 1)
+			c, err := New(3, WithRefresh(func(key any) (any, error) {
+				atomic.AddInt32(&calls, 1)
+				defer func() { done <- struct{}{} }()
+				return tt.refreshVal, tt.refreshErr
+			}))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if err := c.Add(k, v, time.Hour, SoftTTL(time.Millisecond)); err != nil {
+				t.Fatalf("unexpected error, got %v", err)
+			}
+			time.Sleep(10 * time.Millisecond)
+			if val, found := c.Get(k); !found || val != tt.wantServed {
+				t.Errorf("unexpected served value, got %v, %v, want %v, %v", val, found, tt.wantServed, true)
+			}
+			<-done
+			// The refreshed value is stored after the refresh function
+			// returns.
+			time.Sleep(10 * time.Millisecond)
+			item, _ := c.PeekWithInfo(k)
+			if item.Val != tt.wantVal {
+				t.Errorf("unexpected value after refresh, got %v, want %v", item.Val, tt.wantVal)
+			}
+			if calls != 1 {
+				t.Errorf("unexpected refresh calls, got %v, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestSoftTTL(t *testing.T) {
+	c := createCache(t, 3)
+	if err := c.Add(k, v, 10*time.Millisecond, SoftTTL(time.Millisecond)); err != nil {
+		t.Fatalf("unexpected error, got %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	item, found := c.PeekWithInfo(k)
+	if !found || !item.SoftExpired() {
+		t.Errorf("expected stale item to be served, got %+v, %v", item, found)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if _, found := c.Get(k); found {
+		t.Errorf("expected item past its hard TTL not to be served")
+	}
+}
+
+func TestSoftTTL_StaleHits(t *testing.T) {
+	c := createCache(t, 3)
+	if err := c.Add(k, v, time.Hour, SoftTTL(time.Nanosecond)); err != nil {
+		t.Fatalf("unexpected error, got %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, found := c.Get(k); !found {
+		t.Errorf("expected stale item to be served by Get")
+	}
+	if got := c.Stats(); got.StaleHits != 1 || got.Hits != 1 {
+		t.Errorf("unexpected stats, got %+v, want %v stale hit", got, 1)
+	}
+}
@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// configFile is the JSON form of Config read by WatchConfig. Durations are
+// written in the format of time.ParseDuration, e.g. "5m". Settings missing in
+// the file keep their current values.
+type configFile struct {
+	Capacity          *int    `json:"capacity"`
+	DefaultTTL        *string `json:"default_ttl"`
+	GetDoesNotPromote *bool   `json:"get_does_not_promote"`
+	JanitorInterval   *string `json:"janitor_interval"`
+	TTLRules          *[]struct {
+		Pattern string `json:"pattern"`
+		TTL     string `json:"ttl"`
+	} `json:"ttl_rules"`
+}
+
+// WatchConfig reloads the runtime settings of the cache from the JSON file at
+// path whenever the file changes and when the process receives SIGHUP. The
+// file is checked for changes every interval. A file looks like
+//
+//	{
+//		"capacity": 1000,
+//		"default_ttl": "10m",
+//		"ttl_rules": [{"pattern": "user:*", "ttl": "5m"}],
+//		"get_does_not_promote": false,
+//		"janitor_interval": "1m"
+//	}
+//
+// Settings missing in the file keep their current values. The file is loaded
+// once before WatchConfig returns, and its error is returned if it can't be
+// applied. Later reload errors are passed to onError if it is not nil, and the
+// cache keeps its previous settings. It returns error if interval is not
+// positive, without loading the file.
+//
+// It returns a stop function which stops watching. Calling stop more than
+// once is safe.
+func (c *Cache) WatchConfig(path string, interval time.Duration, onError func(error)) (stop func(), err error) {
+	if interval <= 0 {
+		return nil, errInvalidInterval
+	}
+	mod, err := c.reloadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	done := make(chan struct{})
+	go c.labeled("config reload", func(context.Context) {
+		c.watchConfig(path, interval, mod, hup, done, onError)
+	})
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(hup)
+			close(done)
+		})
+	}, nil
+}
+
+// watchConfig reloads the configuration file on SIGHUP or when its
+// modification time changes until done is closed.
+func (c *Cache) watchConfig(path string, interval time.Duration, mod time.Time, hup chan os.Signal, done chan struct{}, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			fi, err := os.Stat(path)
+			if err != nil {
+				if onError != nil {
+					onError(err)
+				}
+				continue
+			}
+			if fi.ModTime().Equal(mod) {
+				continue
+			}
+		case <-hup:
+		case <-done:
+			return
+		}
+
+		c.guard("config reload", func() {
+			// The modification time is kept even if the file is invalid,
+			// so the error is reported once per change.
+			m, err := c.reloadConfig(path)
+			if !m.IsZero() {
+				mod = m
+			}
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		})
+	}
+}
+
+// reloadConfig applies the configuration file at path. It returns the
+// modification time of the file, which is zero if the file can't be opened.
+func (c *Cache) reloadConfig(path string) (time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var file configFile
+	if err := json.NewDecoder(f).Decode(&file); err != nil {
+		return fi.ModTime(), fmt.Errorf("config %s: %w", path, err)
+	}
+	cfg, err := file.apply(c.Config())
+	if err != nil {
+		return fi.ModTime(), fmt.Errorf("config %s: %w", path, err)
+	}
+	return fi.ModTime(), c.Reconfigure(cfg)
+}
+
+// apply overrides the settings of cfg that are present in the file.
+func (file configFile) apply(cfg Config) (Config, error) {
+	if file.Capacity != nil {
+		cfg.Capacity = *file.Capacity
+	}
+	if file.DefaultTTL != nil {
+		d, err := time.ParseDuration(*file.DefaultTTL)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.DefaultTTL = d
+	}
+	if file.GetDoesNotPromote != nil {
+		cfg.GetDoesNotPromote = *file.GetDoesNotPromote
+	}
+	if file.JanitorInterval != nil {
+		d, err := time.ParseDuration(*file.JanitorInterval)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.JanitorInterval = d
+	}
+	if file.TTLRules != nil {
+		cfg.TTLRules = make([]TTLRule, 0, len(*file.TTLRules))
+		for _, rule := range *file.TTLRules {
+			d, err := time.ParseDuration(rule.TTL)
+			if err != nil {
+				return cfg, err
+			}
+			cfg.TTLRules = append(cfg.TTLRules, TTLRule{Pattern: rule.Pattern, TTL: d})
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCache_WatchConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		wantErr    bool
+		wantConfig Config
+	}{
+		{
+			name:       "applies settings in file",
+			file:       `{"capacity": 5, "default_ttl": "10m", "ttl_rules": [{"pattern": "user:*", "ttl": "5m"}], "get_does_not_promote": true, "janitor_interval": "1m"}`,
+			wantConfig: Config{Capacity: 5, DefaultTTL: 10 * time.Minute, TTLRules: []TTLRule{{Pattern: "user:*", TTL: 5 * time.Minute}}, GetDoesNotPromote: true, JanitorInterval: time.Minute},
+		},
+		{
+			name:       "keeps settings missing in file",
+			file:       `{"default_ttl": "1h"}`,
+			wantConfig: Config{Capacity: 3, DefaultTTL: time.Hour, TTLRules: []TTLRule{}},
+		},
+		{
+			name:       "returns error for invalid duration",
+			file:       `{"default_ttl": "soon"}`,
+			wantErr:    true,
+			wantConfig: Config{Capacity: 3, TTLRules: []TTLRule{}},
+		},
+		{
+			name:       "returns error for invalid configuration",
+			file:       `{"capacity": -1}`,
+			wantErr:    true,
+			wantConfig: Config{Capacity: 3, TTLRules: []TTLRule{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cache.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+				t.Fatalf(err.Error())
+			}
+			c := createCache(t, 3)
+			defer c.Close()
+			stop, err := c.WatchConfig(path, time.Hour, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error, got %v, want error %v", err, tt.wantErr)
+			}
+			if stop != nil {
+				stop()
+				stop()
+			}
+			if got := c.Config(); !reflect.DeepEqual(got, tt.wantConfig) {
+				t.Errorf("cache.Config() = %+v, want %+v", got, tt.wantConfig)
+			}
+		})
+	}
+}
+
+func TestCache_WatchConfigInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	if err := os.WriteFile(path, []byte(`{"capacity": 5}`), 0o600); err != nil {
+		t.Fatalf(err.Error())
+	}
+	c := createCache(t, 3)
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if stop, err := c.WatchConfig(path, interval, nil); err != errInvalidInterval || stop != nil {
+			t.Errorf("unexpected result for interval %v, got error %v, want %v", interval, err, errInvalidInterval)
+		}
+	}
+	if got := c.Config().Capacity; got != 3 {
+		t.Errorf("expected file not to be loaded, got capacity %v, want %v", got, 3)
+	}
+}
+
+func TestCache_WatchConfigReload(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		reload   func(t *testing.T)
+	}{
+		{
+			name:     "reloads on file change",
+			interval: 10 * time.Millisecond,
+			reload:   func(t *testing.T) {},
+		},
+		{
+			name:     "reloads on SIGHUP",
+			interval: time.Hour,
+			reload: func(t *testing.T) {
+				p, err := os.FindProcess(os.Getpid())
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+				if err := p.Signal(syscall.SIGHUP); err != nil {
+					t.Fatalf(err.Error())
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cache.json")
+			if err := os.WriteFile(path, []byte(`{"capacity": 5}`), 0o600); err != nil {
+				t.Fatalf(err.Error())
+			}
+			c := createCache(t, 3)
+			stop, err := c.WatchConfig(path, tt.interval, nil)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			defer stop()
+
+			if err := os.WriteFile(path, []byte(`{"capacity": 7}`), 0o600); err != nil {
+				t.Fatalf(err.Error())
+			}
+			// Make sure the modification time changes on coarse clocks.
+			later := time.Now().Add(time.Second)
+			if err := os.Chtimes(path, later, later); err != nil {
+				t.Fatalf(err.Error())
+			}
+			tt.reload(t)
+			for i := 0; i < 100 && c.Cap() != 7; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			if c.Cap() != 7 {
+				t.Errorf("unexpected capacity, got %v, want %v", c.Cap(), 7)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// RepairFunc is called when Get finds an expired item. It receives the stale
+// item and returns the replacement value with its expiration duration and
+// true, or false to report the item as not found and remove it. An expiration
+// duration of 0 means the replacement value never expires.
+type RepairFunc func(stale Item) (val interface{}, exp time.Duration, ok bool)
+
+// WithReadRepair sets the function called when Get finds an expired item,
+// which allows custom revalidation of stale items. The function is called
+// without holding the lock of the cache, so it may be slow or call other
+// methods of the cache.
+func WithReadRepair(fn RepairFunc) Option {
+	return func(c *Cache) {
+		c.repair = fn
+	}
+}
+
+// readRepair calls the repair function for the stale item and stores the
+// replacement value. The value is stored only if the item is still in the
+// cache. If the item is replaced while repairing, the new item is returned
+// instead. The item is promoted if promote is true. ctx is the context passed
+// with Context, or nil.
+func (c *Cache) readRepair(ctx context.Context, stale Item, promote bool) (interface{}, bool) {
+	var val interface{}
+	var exp time.Duration
+	var ok bool
+	// The repair function sees the decoded value and its replacement is
+	// encoded, like the values passed to Get and Add. Values that can't be
+	// decoded or encoded aren't repaired.
+	if stale.Val, ok = c.decoded(stale.Val, true); ok {
+		c.labeledCaller(ctx, "repair", func(context.Context) {
+			if c.guard("repair", func() { val, exp, ok = c.repair(stale) }) != nil {
+				ok = false
+			}
+		})
+	}
+	if ok {
+		var err error
+		val, err = c.encodeVal(val)
+		ok = err == nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, found := c.get(stale.Key)
+	if found && mustItem(e).Created != stale.Created && !mustItem(e).Expired() {
+		return c.access(e, promote), true
+	}
+	if !ok {
+		if found {
+			c.remove(e)
+		}
+		c.miss(stale.Key)
+		return nil, false
+	}
+	if !found {
+		c.hit(stale.Key)
+		return val, true
+	}
+
+	item := mustItem(e)
+	item.Expiration = 0
+	if exp != 0 {
+		item.Expiration = time.Now().Add(exp).UnixNano()
+	}
+	setItem(e, item)
+	if _, err := c.setVal(e, val); err != nil {
+		c.remove(e)
+		c.miss(stale.Key)
+		return nil, false
+	}
+	return c.access(e, promote), true
+}
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithReadRepair(t *testing.T) {
+	tests := []struct {
+		name      string
+		repair    RepairFunc
+		addPairs  [][]any
+		getKey    any
+		want      any
+		wantFound bool
+		wantLen   int
+		wantStats Stats
+	}{
+		{
+			name:      "get reports expired item as not found without repair",
+			repair:    nil,
+			addPairs:  [][]any{{k, v, -1 * time.Hour}},
+			getKey:    k,
+			want:      nil,
+			wantFound: false,
+			wantLen:   0,
+			wantStats: Stats{Misses: 1},
+		},
+		{
+			name: "repair is not called for unexpired item",
+			repair: func(stale Item) (any, time.Duration, bool) {
+				t.Errorf("unexpected repair call for %v", stale.Key)
+				return nil, 0, false
+			},
+			addPairs:  [][]any{{k, v, time.Hour}},
+			getKey:    k,
+			want:      v,
+			wantFound: true,
+			wantLen:   1,
+			wantStats: Stats{Hits: 1},
+		},
+		{
+			name: "repair replaces value of expired item",
+			repair: func(stale Item) (any, time.Duration, bool) {
+				return stale.Val.(string) + v, time.Hour, true
+			},
+			addPairs:  [][]any{{k, v, -1 * time.Hour}},
+			getKey:    k,
+			want:      v + v,
+			wantFound: true,
+			wantLen:   1,
+			wantStats: Stats{Hits: 1},
+		},
+		{
+			name: "repair directs miss for expired item",
+			repair: func(stale Item) (any, time.Duration, bool) {
+				return nil, 0, false
+			},
+			addPairs:  [][]any{{k, v, -1 * time.Hour}, {k + k, v + v, time.Hour}},
+			getKey:    k,
+			want:      nil,
+			wantFound: false,
+			wantLen:   1,
+			wantStats: Stats{Misses: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.repair != nil {
+				opts = append(opts, WithReadRepair(tt.repair))
+			}
+			c, err := New(3, opts...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			addItemsWithExp(t, c, tt.addPairs)
+			got, found := c.Get(tt.getKey)
+			if found != tt.wantFound {
+				t.Errorf("cache.Get() found = %v, want %v", found, tt.wantFound)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cache.Get() = %v, want %v", got, tt.want)
+			}
+			if c.Len() != tt.wantLen {
+				t.Errorf("unexpected length, got %v, want %v", c.Len(), tt.wantLen)
+			}
+			if s := c.Stats(); s != tt.wantStats {
+				t.Errorf("unexpected stats, got %+v, want %+v", s, tt.wantStats)
+			}
+			if item, ok := c.PeekWithInfo(tt.getKey); ok && item.Expired() {
+				t.Errorf("expected repaired item to be unexpired")
+			}
+		})
+	}
+}
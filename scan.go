@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"container/list"
+	"sort"
+)
+
+// Cursor is the position of ScanKeys in the cache. The zero Cursor starts a
+// new scan.
+type Cursor uint64
+
+// ScanKeys returns up to count keys after the cursor and the cursor to pass
+// to the next call, so large caches can be enumerated incrementally without
+// holding the lock for the whole traversal, like the SCAN command of Redis.
+// The returned cursor is zero when the scan is complete. Keys are scanned in
+// the order they are added, except the ones added before the first call,
+// which are scanned from the least recently used one. Keys that exist during
+// the whole scan are returned exactly once, keys added or removed during the
+// scan may or may not be returned. Expired and invalidated items are skipped.
+// It does not change frequency of the item access. The first call builds an
+// index of the items, which is kept up to date afterwards, so each call
+// visits about count items besides the expired and invalidated ones.
+func (c *Cache) ScanKeys(cursor Cursor, count int) ([]interface{}, Cursor) {
+	if count < 1 {
+		count = 1
+	}
+	// The write lock is taken, since the index is built lazily and
+	// compacted by the scan.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.scanIdx == nil {
+		c.scanIdx = newScanIndex(c.lst)
+	}
+	idx := c.scanIdx
+
+	keys := make([]interface{}, 0, count)
+	i := sort.Search(len(idx.order), func(i int) bool { return idx.order[i].seq > uint64(cursor) })
+	for ; i < len(idx.order); i++ {
+		pos := idx.order[i]
+		if idx.seqOf[pos.e] != pos.seq {
+			continue
+		}
+		item := mustItem(pos.e)
+		if c.stale(item) || item.Expired() {
+			continue
+		}
+		if len(keys) == count {
+			// There are more keys, so the scan resumes after the last
+			// returned one.
+			return keys, cursor
+		}
+		keys = append(keys, item.Key)
+		cursor = Cursor(pos.seq)
+	}
+	return keys, 0
+}
+
+// scanIndex orders the elements of the items by sequence numbers for
+// ScanKeys. The numbers are kept by the index, so the items don't carry them
+// unless a scan is started.
+type scanIndex struct {
+	// seq is the sequence number of the last indexed element.
+	seq uint64
+
+	// order keeps the elements in ascending order of their sequence numbers.
+	// It may have the elements of the removed items, until it is compacted.
+	order []scanPos
+
+	// seqOf keeps the sequence number of each element in the cache.
+	seqOf map[*list.Element]uint64
+}
+
+// scanPos is an element of the list with its sequence number.
+type scanPos struct {
+	seq uint64
+	e   *list.Element
+}
+
+// newScanIndex returns the index of the elements of lst, numbered from the
+// back of the list.
+func newScanIndex(lst *list.List) *scanIndex {
+	idx := &scanIndex{
+		order: make([]scanPos, 0, lst.Len()),
+		seqOf: make(map[*list.Element]uint64, lst.Len()),
+	}
+	for e := lst.Back(); e != nil; e = e.Prev() {
+		idx.add(e)
+	}
+	return idx
+}
+
+// add numbers the element of a new item after the indexed ones. The removed
+// elements are dropped once they are the majority, so the index stays
+// proportional to the cache.
+func (idx *scanIndex) add(e *list.Element) {
+	if idx == nil {
+		return
+	}
+	if len(idx.order) >= 2*len(idx.seqOf)+16 {
+		order := idx.order[:0]
+		for _, pos := range idx.order {
+			if idx.seqOf[pos.e] == pos.seq {
+				order = append(order, pos)
+			}
+		}
+		for i := len(order); i < len(idx.order); i++ {
+			idx.order[i] = scanPos{}
+		}
+		idx.order = order
+	}
+	idx.seq++
+	idx.order = append(idx.order, scanPos{seq: idx.seq, e: e})
+	idx.seqOf[e] = idx.seq
+}
+
+// remove drops the element from the index.
+func (idx *scanIndex) remove(e *list.Element) {
+	if idx == nil {
+		return
+	}
+	delete(idx.seqOf, e)
+}
+
+// shrink drops the removed elements and reallocates the index to its current
+// size, since Go maps never shrink.
+func (idx *scanIndex) shrink() {
+	if idx == nil {
+		return
+	}
+	order := make([]scanPos, 0, len(idx.seqOf))
+	seqOf := make(map[*list.Element]uint64, len(idx.seqOf))
+	for _, pos := range idx.order {
+		if idx.seqOf[pos.e] == pos.seq {
+			order = append(order, pos)
+			seqOf[pos.e] = pos.seq
+		}
+	}
+	idx.order = order
+	idx.seqOf = seqOf
+}
+
+// move gives the sequence number of the element from to the element to, which
+// replaces it in a rebuilt list.
+func (idx *scanIndex) move(from, to *list.Element) {
+	if idx == nil {
+		return
+	}
+	seq, ok := idx.seqOf[from]
+	if !ok {
+		return
+	}
+	delete(idx.seqOf, from)
+	idx.seqOf[to] = seq
+	i := sort.Search(len(idx.order), func(i int) bool { return idx.order[i].seq >= seq })
+	idx.order[i].e = to
+}
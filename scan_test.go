@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCache_ScanKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		addPairs  [][]any
+		count     int
+		wantPages [][]any
+	}{
+		{
+			name:      "returns empty page for empty cache",
+			addPairs:  [][]any{},
+			count:     2,
+			wantPages: [][]any{{}},
+		},
+		{
+			name:      "returns keys in insertion order",
+			addPairs:  [][]any{{"1", v, time.Duration(0)}, {"2", v, time.Duration(0)}, {"3", v, time.Duration(0)}, {"4", v, time.Duration(0)}, {"5", v, time.Duration(0)}},
+			count:     2,
+			wantPages: [][]any{{"1", "2"}, {"3", "4"}, {"5"}},
+		},
+		{
+			name:      "skips expired items",
+			addPairs:  [][]any{{"1", v, time.Duration(0)}, {"2", v, -time.Hour}, {"3", v, time.Hour}},
+			count:     5,
+			wantPages: [][]any{{"1", "3"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := createCache(t, 5)
+			addItemsWithExp(t, c, tt.addPairs)
+			var cursor Cursor
+			for i, want := range tt.wantPages {
+				var keys []any
+				keys, cursor = c.ScanKeys(cursor, tt.count)
+				if !reflect.DeepEqual(keys, want) {
+					t.Errorf("unexpected page %d, got %v, want %v", i, keys, want)
+				}
+				if last := i == len(tt.wantPages)-1; last != (cursor == 0) {
+					t.Errorf("unexpected cursor %v after page %d", cursor, i)
+				}
+			}
+		})
+	}
+}
+
+func TestCache_ScanKeysConcurrentChanges(t *testing.T) {
+	c := createCache(t, 10)
+	addItems(t, c, [][]any{{"1", v}, {"2", v}, {"3", v}, {"4", v}, {"5", v}, {"6", v}})
+
+	seen := make(map[any]int)
+	keys, cursor := c.ScanKeys(0, 2)
+	for _, key := range keys {
+		seen[key]++
+	}
+	// Promoting, removing and adding items between calls must not make the
+	// scan skip or repeat the items that exist during the whole scan.
+	c.Get("1")
+	c.Get("5")
+	c.Remove("3")
+	addItems(t, c, [][]any{{"7", v}})
+	for cursor != 0 {
+		keys, cursor = c.ScanKeys(cursor, 2)
+		for _, key := range keys {
+			seen[key]++
+		}
+	}
+	for _, key := range []any{"1", "2", "4", "5", "6", "7"} {
+		if seen[key] != 1 {
+			t.Errorf("expected key %v to be returned once, got %v times", key, seen[key])
+		}
+	}
+	if seen["3"] != 0 {
+		t.Errorf("expected removed key to be skipped")
+	}
+}
+
+func TestCache_ScanKeysIndex(t *testing.T) {
+	c := createCache(t, 10)
+	addItems(t, c, [][]any{{"1", v}, {"2", v}})
+	if keys, _ := c.ScanKeys(0, 1); !reflect.DeepEqual(keys, []any{"1"}) {
+		t.Fatalf("unexpected page, got %v, want %v", keys, []any{"1"})
+	}
+
+	// Removed items must not make the index grow beyond the cache.
+	for i := 0; i < 1000; i++ {
+		addItems(t, c, [][]any{{i, v}})
+		c.Remove(i)
+	}
+	if n := len(c.scanIdx.order); n > 2*c.Len()+17 {
+		t.Errorf("expected index to be compacted, got %v sequence numbers for %v items", n, c.Len())
+	}
+
+	// Compact replaces the elements, so the index is rebuilt.
+	c.Compact()
+	addItems(t, c, [][]any{{"3", v}})
+	var all []any
+	for cursor := Cursor(0); ; {
+		var keys []any
+		keys, cursor = c.ScanKeys(cursor, 2)
+		all = append(all, keys...)
+		if cursor == 0 {
+			break
+		}
+	}
+	if want := []any{"1", "2", "3"}; !reflect.DeepEqual(all, want) {
+		t.Errorf("unexpected keys, got %v, want %v", all, want)
+	}
+}
@@ -0,0 +1,262 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// schedule is a parsed cron-like specification. Each field keeps the set of
+// allowed values as a bit mask.
+type schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// domStar and dowStar report whether the day-of-month and day-of-week
+	// fields are wildcards. Cron matches a day if either of them matches when
+	// both are restricted.
+	domStar, dowStar bool
+}
+
+// bounds is the allowed range of a cron field.
+type bounds struct {
+	min, max int
+}
+
+var (
+	minuteBounds = bounds{0, 59}
+	hourBounds   = bounds{0, 23}
+	domBounds    = bounds{1, 31}
+	monthBounds  = bounds{1, 12}
+	dowBounds    = bounds{0, 6}
+)
+
+// descriptors are the predefined schedules that can be used instead of the
+// five fields.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ScheduleClear clears the whole cache on a recurring schedule described by
+// spec. The spec uses the standard five cron fields (minute, hour, day of
+// month, month, day of week) separated by spaces. Each field accepts "*",
+// single values, ranges ("1-5"), lists ("1,15") and steps ("*/10", "0-30/5").
+// Descriptors like "@daily" and "@hourly" are also supported. The schedule is
+// evaluated in the local time zone.
+//
+// It returns a stop function which cancels the schedule. Calling stop more
+// than once is safe.
+func (c *Cache) ScheduleClear(spec string) (stop func(), err error) {
+	return c.runOnSchedule(spec, "clear", c.Clear)
+}
+
+// ScheduleClearNamespace invalidates all keys in the namespace ns with
+// BumpNamespace on a recurring schedule described by spec, see ScheduleClear
+// for the format. The other namespaces are kept.
+//
+// It returns a stop function which cancels the schedule. Calling stop more
+// than once is safe.
+func (c *Cache) ScheduleClearNamespace(spec, ns string) (stop func(), err error) {
+	return c.runOnSchedule(spec, "clear namespace", func() { c.BumpNamespace(ns) })
+}
+
+// runOnSchedule calls fn each time the schedule described by spec fires,
+// until the returned stop function is called. The goroutine running fn is
+// labeled with op for CPU profiles.
+func (c *Cache) runOnSchedule(spec, op string, fn func()) (stop func(), err error) {
+	s, err := parseSchedule(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	run := func() { c.guard(op, fn) }
+	go c.labeled(op, func(context.Context) { s.run(run, done) })
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}, nil
+}
+
+// run calls fn each time the schedule fires until done is closed.
+func (s *schedule) run(fn func(), done chan struct{}) {
+	for {
+		next := s.next(time.Now())
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+			fn()
+		case <-done:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// parseSchedule parses the cron-like spec. See ScheduleClear for the format.
+func parseSchedule(spec string) (*schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if d, ok := descriptors[spec]; ok {
+		spec = d
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 fields, got %d", errInvalidSchedule, len(fields))
+	}
+
+	s := &schedule{
+		domStar: fields[2] == "*",
+		dowStar: fields[4] == "*",
+	}
+	var err error
+	if s.minute, err = parseField(fields[0], minuteBounds); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], hourBounds); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(fields[2], domBounds); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], monthBounds); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(fields[4], dowBounds); err != nil {
+		return nil, err
+	}
+	if !s.satisfiable() {
+		return nil, fmt.Errorf("%w: %q never matches", errInvalidSchedule, spec)
+	}
+	return s, nil
+}
+
+// daysIn is the largest day of each month, counting February 29.
+var daysIn = [13]int{0, 31, 29, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// satisfiable reports whether the schedule matches any day. The day of month
+// alone can't match, e.g. in "0 0 31 2 *", only if the day of week is a
+// wildcard, since either of them matches when both are restricted.
+func (s *schedule) satisfiable() bool {
+	if s.domStar || !s.dowStar {
+		return true
+	}
+	for m := monthBounds.min; m <= monthBounds.max; m++ {
+		if s.month&(1<<uint(m)) == 0 {
+			continue
+		}
+		for d := domBounds.min; d <= daysIn[m]; d++ {
+			if s.dom&(1<<uint(d)) != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseField parses a single comma separated cron field and returns the bit
+// mask of the allowed values.
+func parseField(field string, b bounds) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(field, ",") {
+		m, err := parseRange(part, b)
+		if err != nil {
+			return 0, err
+		}
+		mask |= m
+	}
+	return mask, nil
+}
+
+// parseRange parses one element of a cron field, which is either "*", a
+// value, or a range, optionally followed by a step.
+func parseRange(expr string, b bounds) (uint64, error) {
+	rng, step := expr, 1
+	if i := strings.IndexByte(expr, '/'); i >= 0 {
+		var err error
+		rng = expr[:i]
+		step, err = strconv.Atoi(expr[i+1:])
+		if err != nil || step <= 0 {
+			return 0, fmt.Errorf("%w: invalid step in %q", errInvalidSchedule, expr)
+		}
+	}
+
+	lo, hi := b.min, b.max
+	if rng != "*" {
+		var err error
+		lohi := strings.SplitN(rng, "-", 2)
+		if lo, err = strconv.Atoi(lohi[0]); err != nil {
+			return 0, fmt.Errorf("%w: invalid value in %q", errInvalidSchedule, expr)
+		}
+		hi = lo
+		if len(lohi) == 2 {
+			if hi, err = strconv.Atoi(lohi[1]); err != nil {
+				return 0, fmt.Errorf("%w: invalid value in %q", errInvalidSchedule, expr)
+			}
+		} else if step != 1 {
+			hi = b.max
+		}
+	}
+	if lo < b.min || hi > b.max || lo > hi {
+		return 0, fmt.Errorf("%w: %q out of range [%d, %d]", errInvalidSchedule, expr, b.min, b.max)
+	}
+
+	var mask uint64
+	for i := lo; i <= hi; i += step {
+		mask |= 1 << uint(i)
+	}
+	return mask, nil
+}
+
+// next returns the first time after t that matches the schedule. Hours and
+// days are stepped in the location of t, so the schedule fires at the wall
+// clock times of zones whose offset is not a whole number of hours too.
+// Minutes are stepped in absolute time, so the repeated wall clock times of a
+// daylight saving fall-back never make it return a time before t.
+func (s *schedule) next(t time.Time) time.Time {
+	after := t
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// parseSchedule rejects the specs which never match, and February 29
+	// matches at least once in 8 years, so the limit only guards against a
+	// bug looping forever.
+	limit := t.AddDate(10, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 || !t.After(after) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return limit
+}
+
+// dayMatches reports whether the day of t matches the day-of-month and
+// day-of-week fields.
+func (s *schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,213 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCache_ScheduleClear(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr error
+	}{
+		{
+			name:    "returns error for spec with missing fields",
+			spec:    "0 0 * *",
+			wantErr: errInvalidSchedule,
+		},
+		{
+			name:    "returns error for out of range value",
+			spec:    "60 0 * * *",
+			wantErr: errInvalidSchedule,
+		},
+		{
+			name:    "returns error for invalid step",
+			spec:    "*/0 * * * *",
+			wantErr: errInvalidSchedule,
+		},
+		{
+			name:    "returns error for unknown descriptor",
+			spec:    "@sometimes",
+			wantErr: errInvalidSchedule,
+		},
+		{
+			name:    "returns error for spec which never matches",
+			spec:    "0 0 31 2 *",
+			wantErr: errInvalidSchedule,
+		},
+		{
+			name:    "returns error for days which never exist in months",
+			spec:    "0 0 30,31 2 *",
+			wantErr: errInvalidSchedule,
+		},
+		{
+			name:    "schedules clear for leap day",
+			spec:    "0 0 29 2 *",
+			wantErr: nil,
+		},
+		{
+			name:    "schedules clear for missing day when day of week matches",
+			spec:    "0 0 31 2 1",
+			wantErr: nil,
+		},
+		{
+			name:    "schedules clear for valid spec",
+			spec:    "*/5 * * * *",
+			wantErr: nil,
+		},
+		{
+			name:    "schedules clear for descriptor",
+			spec:    "@daily",
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		c := createCache(t, 1)
+		t.Run(tt.name, func(t *testing.T) {
+			stop, err := c.ScheduleClear(tt.spec)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("cache.ScheduleClear() error = %v, want %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			stop()
+			stop()
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	from := time.Date(2022, time.March, 15, 10, 30, 20, 0, time.UTC) // Tuesday
+	kolkata := time.FixedZone("IST", 5*3600+1800)
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "every minute fires at the next minute",
+			spec: "* * * * *",
+			want: time.Date(2022, time.March, 15, 10, 31, 0, 0, time.UTC),
+		},
+		{
+			name: "every 15 minutes fires at the next quarter",
+			spec: "*/15 * * * *",
+			want: time.Date(2022, time.March, 15, 10, 45, 0, 0, time.UTC),
+		},
+		{
+			name: "daily fires at the next midnight",
+			spec: "@daily",
+			want: time.Date(2022, time.March, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "hour list picks the next hour in list",
+			spec: "0 9,17 * * *",
+			want: time.Date(2022, time.March, 15, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "day of week skips to the next matching weekday",
+			spec: "0 6 * * 6",
+			want: time.Date(2022, time.March, 19, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "month rolls over to the next year",
+			spec: "0 0 1 1 *",
+			want: time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "restricted day of month and day of week match either",
+			spec: "0 0 20 * 3",
+			want: time.Date(2022, time.March, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "hour fires at wall clock time of half-hour offset zone",
+			spec: "0 11 * * *",
+			from: time.Date(2022, time.March, 15, 10, 46, 0, 0, kolkata),
+			want: time.Date(2022, time.March, 15, 11, 0, 0, 0, kolkata),
+		},
+		{
+			name: "leap day fires in the next leap year",
+			spec: "0 0 29 2 *",
+			want: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := parseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("unexpected error, got %v", err)
+			}
+			start := tt.from
+			if start.IsZero() {
+				start = from
+			}
+			if got := s.next(start); !got.Equal(tt.want) {
+				t.Errorf("unexpected next time, got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedule_NextFallBack(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database is not available: %v", err)
+	}
+	// Clocks go back from 02:00 EDT to 01:00 EST on November 6, 2022, so
+	// the wall clock times between 01:00 and 02:00 repeat.
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "every minute fires after repeated wall clock time",
+			spec: "* * * * *",
+			from: time.Date(2022, time.November, 6, 6, 30, 5, 0, time.UTC).In(newYork), // 01:30:05 EST
+			want: time.Date(2022, time.November, 6, 6, 31, 0, 0, time.UTC),
+		},
+		{
+			name: "hourly fires at repeated hour",
+			spec: "0 * * * *",
+			from: time.Date(2022, time.November, 6, 5, 30, 0, 0, time.UTC).In(newYork), // 01:30 EDT
+			want: time.Date(2022, time.November, 6, 6, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := parseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("unexpected error, got %v", err)
+			}
+			got := s.next(tt.from)
+			if !got.After(tt.from) {
+				t.Fatalf("expected next time after %v, got %v", tt.from, got)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("unexpected next time, got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCache_ScheduleClearNamespace(t *testing.T) {
+	c, err := New(3, WithNamespace(PrefixNamespace(":")))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if _, err := c.ScheduleClearNamespace("0 0 31 2 *", "a"); !errors.Is(err, errInvalidSchedule) {
+		t.Errorf("unexpected error, got %v, want %v", err, errInvalidSchedule)
+	}
+	stop, err := c.ScheduleClearNamespace("* * * * *", "a")
+	if err != nil {
+		t.Fatalf("unexpected error, got %v", err)
+	}
+	stop()
+	stop()
+}
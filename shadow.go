@@ -0,0 +1,42 @@
+package cache
+
+// WithShadow mirrors the operations of the cache into the shadow cache,
+// without serving from it, so a different configuration can be evaluated on
+// production traffic before it is rolled out. Add, Get, Remove and Clear are
+// mirrored. When Get finds an item that the shadow doesn't have, the item is
+// added to the shadow as if it is loaded, so both caches see the same
+// workload. Comparing the hit ratios of their Stats tells which one performs
+// better. The shadow is called without holding the lock of the cache, and it
+// must not be shared by other caches.
+func WithShadow(shadow *Cache) Option {
+	return func(c *Cache) {
+		c.shadow = shadow
+	}
+}
+
+// mirrorGet mirrors a Get call of the cache, which returned val and found, to
+// the shadow.
+func (c *Cache) mirrorGet(key, val interface{}, found bool, opts []CallOption) {
+	if _, ok := c.shadow.Get(key, mirrored(opts)...); ok || !found {
+		return
+	}
+	item, ok := c.PeekWithInfo(key)
+	if !ok {
+		return
+	}
+	exp := item.RemainingTTL()
+	if exp < 0 {
+		exp = 0
+	}
+	c.shadow.Add(key, val, exp)
+}
+
+// mirrored returns the options of a call for the shadow, which doesn't store
+// its reports in the ServeInfo and the AddReport passed with Served and Report
+// by the caller.
+func mirrored(opts []CallOption) []CallOption {
+	return append(opts[:len(opts):len(opts)], func(cfg *callConfig) {
+		cfg.served = nil
+		cfg.report = nil
+	})
+}
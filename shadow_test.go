@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithShadow(t *testing.T) {
+	tests := []struct {
+		name            string
+		capacity        int
+		shadowCapacity  int
+		keys            []any
+		rounds          int
+		wantStats       Stats
+		wantShadowStats Stats
+	}{
+		{
+			name:            "bigger shadow fits cyclic working set",
+			capacity:        2,
+			shadowCapacity:  3,
+			keys:            []any{1, 2, 3},
+			rounds:          3,
+			wantStats:       Stats{Misses: 9, Evictions: 7},
+			wantShadowStats: Stats{Hits: 6, Misses: 3},
+		},
+		{
+			name:            "same capacity gives same stats",
+			capacity:        2,
+			shadowCapacity:  2,
+			keys:            []any{1, 2, 1, 2},
+			rounds:          2,
+			wantStats:       Stats{Hits: 6, Misses: 2},
+			wantShadowStats: Stats{Hits: 6, Misses: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shadow := createCache(t, tt.shadowCapacity)
+			c, err := New(tt.capacity, WithShadow(shadow))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			for i := 0; i < tt.rounds; i++ {
+				for _, key := range tt.keys {
+					if _, found := c.Get(key); !found {
+						c.Add(key, v, 0)
+					}
+				}
+			}
+			if got := c.Stats(); got != tt.wantStats {
+				t.Errorf("unexpected stats, got %+v, want %+v", got, tt.wantStats)
+			}
+			if got := shadow.Stats(); got != tt.wantShadowStats {
+				t.Errorf("unexpected shadow stats, got %+v, want %+v", got, tt.wantShadowStats)
+			}
+
+			c.Remove(tt.keys[0])
+			if shadow.Contains(tt.keys[0]) {
+				t.Errorf("expected %v to be removed from shadow", tt.keys[0])
+			}
+			c.Clear()
+			if shadow.Len() != 0 {
+				t.Errorf("expected shadow to be cleared, got length %v", shadow.Len())
+			}
+		})
+	}
+}
+
+func TestWithShadow_Report(t *testing.T) {
+	shadow := createCache(t, 1)
+	c, err := New(2, WithShadow(shadow))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	addItems(t, c, [][]any{{k, v}})
+	var r AddReport
+	if err := c.Add(k+k, v, 0, Report(&r)); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if r.Evicted != 0 {
+		t.Errorf("expected report of the cache, not the shadow, got %+v", r)
+	}
+}
+
+func TestWithShadow_Served(t *testing.T) {
+	shadow := createCache(t, 1)
+	if err := shadow.Add(k, v, 0, SoftTTL(time.Nanosecond)); err != nil {
+		t.Fatalf(err.Error())
+	}
+	c, err := New(2, WithShadow(shadow))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := c.Add(k, v, 0); err != nil {
+		t.Fatalf(err.Error())
+	}
+	time.Sleep(time.Millisecond)
+	var info ServeInfo
+	if _, found := c.Get(k, Served(&info)); !found {
+		t.Fatalf("expected key %v to be found", k)
+	}
+	if info.Origin != FromCache {
+		t.Errorf("expected origin of the cache, not the shadow, got %v, want %v", info.Origin, FromCache)
+	}
+}
@@ -0,0 +1,312 @@
+package cache
+
+import (
+	"container/list"
+	"sort"
+	"time"
+)
+
+// Sizer is implemented by values which report their size in bytes, see
+// WithMaxBytes.
+type Sizer interface {
+	// Size returns the size of the value in bytes.
+	Size() int64
+}
+
+// WithMaxBytes limits the total size of the items in bytes, in addition to
+// the capacity passed to New, which can be set high to limit the cache by
+// size only. The least recently used items are evicted until a new item
+// fits. The size of an item is returned by sizeFn if it is not nil.
+// Otherwise, values implementing Sizer report their own size, and the size
+// of []byte and string values is their length. Add, Replace and UpdateVal
+// return ErrUnknownSize for other values and for negative sizes, and
+// ErrTooLarge for values bigger than max. sizeFn is called while holding the
+// lock of the cache, so it must not call the methods of the cache.
+func WithMaxBytes(max int64, sizeFn func(key, val interface{}) int64) Option {
+	return func(c *Cache) {
+		if max <= 0 {
+			return
+		}
+		c.maxCost = max
+		c.weighted = false
+		c.sizeFn = sizeFn
+	}
+}
+
+// WithMaxCost limits the total cost of the items, in addition to the capacity
+// passed to New. The cost of an item is an arbitrary weight passed to
+// AddWithCost, like the expense of the query which computed it, and it is 1
+// for the items added otherwise. The least recently used items are evicted
+// until a new item fits. It replaces the limit set with WithMaxBytes.
+func WithMaxCost(max int64) Option {
+	return func(c *Cache) {
+		if max <= 0 {
+			return
+		}
+		c.maxCost = max
+		c.weighted = true
+		c.sizeFn = nil
+	}
+}
+
+// AddWithCost is like Add, but the item weighs cost against the limit set with
+// WithMaxCost. It returns error if cost is negative, and ErrTooLarge if it is
+// bigger than the limit. Replace and UpdateVal keep the cost of the item, use
+// UpdateCost when the value grows or shrinks.
+func (c *Cache) AddWithCost(key interface{}, val interface{}, cost int64, exp time.Duration, opts ...CallOption) error {
+	if cost < 0 {
+		return errNegativeCost
+	}
+	return c.Add(key, val, exp, append(opts, func(cfg *callConfig) {
+		cfg.cost = cost
+		cfg.hasCost = true
+	})...)
+}
+
+// UpdateCost changes the cost of the item of the key, see AddWithCost. The
+// least recently used other items are evicted until the new cost fits the
+// limit set with WithMaxCost. It returns ErrTooLarge and keeps the old cost if
+// the new one doesn't fit, and error if cost is negative, the key does not
+// exist or the cache is not created with WithMaxCost. The cache order is not
+// changed.
+func (c *Cache) UpdateCost(key interface{}, cost int64) (err error) {
+	defer recoverCorrupt(&err)
+	if cost < 0 {
+		return errNegativeCost
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.weighted {
+		return errNotWeighted
+	}
+	e, found := c.get(key)
+	if !found {
+		return errKeyNotExist
+	}
+	item := mustItem(e)
+	if cost > c.maxCost || !c.fitCost(cost-item.Cost, e) {
+		return ErrTooLarge
+	}
+	c.cost += cost - item.Cost
+	item.Cost = cost
+	setItem(e, item)
+	return nil
+}
+
+// AddReport is the effect of an Add call on the budget set with WithMaxBytes
+// or WithMaxCost, see Report.
+type AddReport struct {
+	// Evicted is the number of items evicted to make room for the item.
+	Evicted int
+
+	// EvictedCost is the total cost of the evicted items.
+	EvictedCost int64
+
+	// Cost is the total cost of the items after the call, like Cost and
+	// Bytes.
+	Cost int64
+
+	// Max is the limit set with WithMaxBytes or WithMaxCost, or 0.
+	Max int64
+}
+
+// Utilization returns the ratio of the budget in use after the call. It is 0
+// if the cost is not limited.
+func (r AddReport) Utilization() float64 {
+	if r.Max == 0 {
+		return 0
+	}
+	return float64(r.Cost) / float64(r.Max)
+}
+
+// Report makes Add and AddWithCost store in r the items they evicted and the
+// utilization of the budget, so the application can price its items, e.g.
+// stop caching a kind of value which evicts too much. r is not changed if
+// nothing is stored since the call is marked with NoStore or Bypass, or the
+// item is not admitted, valid or encodable.
+func Report(r *AddReport) CallOption {
+	return func(cfg *callConfig) {
+		cfg.report = r
+	}
+}
+
+// reportAdd records the counters before an add and returns the function
+// which stores its effect in r. It must be called while holding the lock of
+// the cache.
+func (c *Cache) reportAdd(r *AddReport) func() {
+	evictions, cost := c.stats.Evictions, c.evictedCost
+	return func() {
+		*r = AddReport{
+			Evicted:     int(c.stats.Evictions - evictions),
+			EvictedCost: c.evictedCost - cost,
+			Cost:        c.cost,
+			Max:         c.maxCost,
+		}
+	}
+}
+
+// costOf returns the cost of the item counted against the budget set with
+// WithMaxBytes or WithMaxCost. It is 0 if there is no budget.
+func (c *Cache) costOf(item Item) (int64, error) {
+	if c.maxCost == 0 {
+		return 0, nil
+	}
+	cost := item.Cost
+	if !c.weighted {
+		var err error
+		if cost, err = c.sizeOf(item.Key, item.Val); err != nil {
+			return 0, err
+		}
+	}
+	if cost > c.maxCost {
+		return 0, ErrTooLarge
+	}
+	return cost, nil
+}
+
+// sizeOf returns the size of the value in bytes. A negative size reported by
+// sizeFn or a Sizer is unknown, since it would corrupt the total size.
+func (c *Cache) sizeOf(key, val interface{}) (int64, error) {
+	var size int64
+	if c.sizeFn != nil {
+		size = c.sizeFn(key, val)
+	} else {
+		switch v := val.(type) {
+		case Sizer:
+			size = v.Size()
+		case []byte:
+			return int64(len(v)), nil
+		case string:
+			return int64(len(v)), nil
+		default:
+			return 0, ErrUnknownSize
+		}
+	}
+	if size < 0 {
+		return 0, ErrUnknownSize
+	}
+	return size, nil
+}
+
+// setVal changes the value of the element and its cost, evicting the least
+// recently used other items if the budget set with WithMaxBytes is exceeded.
+// The element itself is never evicted. If the new value doesn't fit, the old
+// value is kept and error is returned. It returns the changed item.
+func (c *Cache) setVal(e *list.Element, val interface{}) (Item, error) {
+	item := mustItem(e)
+	changed := item
+	changed.Val = val
+	cost, err := c.costOf(changed)
+	if err != nil {
+		return item, err
+	}
+	if !c.fitCost(cost-item.Cost, e) {
+		return item, ErrTooLarge
+	}
+	item.Val = val
+	c.cost += cost - item.Cost
+	item.Cost = cost
+	setItem(e, item)
+	return item, nil
+}
+
+// fitCost evicts the least recently used items other than keep until the
+// given extra cost fits the budget set with WithMaxBytes or WithMaxCost. keep
+// may be nil. It returns false if the cost doesn't fit after evicting all
+// other items.
+func (c *Cache) fitCost(cost int64, keep *list.Element) bool {
+	for c.maxCost > 0 && c.cost+cost > c.maxCost {
+		e := c.victim(keep)
+		if e == nil {
+			return false
+		}
+		c.evict(e, EvictSize)
+	}
+	return true
+}
+
+// hasCostFor reports whether the item can be added without evicting others to
+// stay within the size or cost limit.
+func (c *Cache) hasCostFor(key, val interface{}, cfg callConfig) bool {
+	if c.maxCost == 0 {
+		return true
+	}
+	item := Item{Key: key, Val: val, Cost: cfg.cost}
+	if c.weighted && !cfg.hasCost {
+		item.Cost = 1
+	}
+	cost, err := c.costOf(item)
+	return err == nil && c.cost+cost <= c.maxCost
+}
+
+// Bytes returns the total size of the items in bytes, see WithMaxBytes. It is
+// 0 if the size is not limited.
+func (c *Cache) Bytes() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cost
+}
+
+// Cost returns the total cost of the items, see WithMaxCost. It is 0 if the
+// cost is not limited.
+func (c *Cache) Cost() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cost
+}
+
+// CostReport summarizes what consumes the budget set with WithMaxBytes or
+// WithMaxCost, see CostReport.
+type CostReport struct {
+	// Total is the total cost of the items, like Cost and Bytes.
+	Total int64
+
+	// Max is the limit set with WithMaxBytes or WithMaxCost.
+	Max int64
+
+	// Namespaces is the total cost of the items of each namespace, see
+	// WithNamespace.
+	Namespaces map[string]int64
+
+	// Top is the costliest items, the costliest one first. Items of the same
+	// cost are ordered from the most recently used one.
+	Top []CostEntry
+}
+
+// CostEntry is an item in CostReport.
+type CostEntry struct {
+	Key       interface{}
+	Namespace string
+	Cost      int64
+}
+
+// CostReport returns the total cost of the items, their cost by namespace and
+// the n costliest items. Expired and invalidated items are counted until they
+// are removed, since they hold their cost until then. The report is empty if
+// the cache is created without WithMaxBytes or WithMaxCost.
+func (c *Cache) CostReport(n int) CostReport {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	r := CostReport{Total: c.cost, Max: c.maxCost, Namespaces: make(map[string]int64)}
+	if c.maxCost == 0 {
+		return r
+	}
+	entries := make([]CostEntry, 0, c.len)
+	for e := c.lst.Front(); e != nil; e = e.Next() {
+		item := mustItem(e)
+		ns := c.namespace(item.Key)
+		r.Namespaces[ns] += item.Cost
+		entries = append(entries, CostEntry{Key: item.Key, Namespace: ns, Cost: item.Cost})
+	}
+	if n <= 0 {
+		return r
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Cost > entries[j].Cost
+	})
+	if n < len(entries) {
+		entries = entries[:n]
+	}
+	r.Top = entries
+	return r
+}
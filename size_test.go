@@ -0,0 +1,403 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// sized is a value reporting its size.
+type sized int64
+
+func (s sized) Size() int64 { return int64(s) }
+
+func TestWithMaxBytes(t *testing.T) {
+	tests := []struct {
+		name              string
+		sizeFn            func(key, val any) int64
+		addPairs          [][]any
+		replace           []any
+		wantErr           error
+		wantBytes         int64
+		wantKeysListOrder []any
+	}{
+		{
+			name:              "evicts least recently used items until new item fits",
+			addPairs:          [][]any{{"a", "1234"}, {"b", "1234"}, {"c", "1234"}},
+			wantBytes:         8,
+			wantKeysListOrder: []any{"c", "b"},
+		},
+		{
+			name:              "uses size reported by values",
+			addPairs:          [][]any{{"a", sized(3)}, {"b", []byte("12345")}, {"c", sized(5)}},
+			wantBytes:         10,
+			wantKeysListOrder: []any{"c", "b"},
+		},
+		{
+			name:              "uses size function",
+			sizeFn:            func(key, val any) int64 { return 5 },
+			addPairs:          [][]any{{"a", 1}, {"b", 2}, {"c", 3}},
+			wantBytes:         10,
+			wantKeysListOrder: []any{"c", "b"},
+		},
+		{
+			name:              "rejects value of unknown size",
+			addPairs:          [][]any{{"a", 1}},
+			wantErr:           ErrUnknownSize,
+			wantKeysListOrder: []any{},
+		},
+		{
+			name:              "rejects negative size reported by value",
+			addPairs:          [][]any{{"a", "1234"}, {"b", sized(-5)}},
+			wantErr:           ErrUnknownSize,
+			wantBytes:         4,
+			wantKeysListOrder: []any{"a"},
+		},
+		{
+			name:              "rejects negative size returned by size function",
+			sizeFn:            func(key, val any) int64 { return -1 },
+			addPairs:          [][]any{{"a", 1}},
+			wantErr:           ErrUnknownSize,
+			wantKeysListOrder: []any{},
+		},
+		{
+			name:              "rejects value bigger than limit",
+			addPairs:          [][]any{{"a", "1"}, {"b", "12345678901"}},
+			wantErr:           ErrTooLarge,
+			wantBytes:         1,
+			wantKeysListOrder: []any{"a"},
+		},
+		{
+			name:              "evicts when replaced value grows",
+			addPairs:          [][]any{{"a", "1234"}, {"b", "1234"}},
+			replace:           []any{"b", "12345678"},
+			wantBytes:         8,
+			wantKeysListOrder: []any{"b"},
+		},
+		{
+			name:              "keeps least recently used item when its value grows",
+			addPairs:          [][]any{{"a", "12345"}, {"b", "12345"}},
+			replace:           []any{"a", "12345678"},
+			wantBytes:         8,
+			wantKeysListOrder: []any{"a"},
+		},
+		{
+			name:              "keeps old value when replaced value is bigger than limit",
+			addPairs:          [][]any{{"a", "12345"}, {"b", "12345"}},
+			replace:           []any{"a", "12345678901"},
+			wantErr:           ErrTooLarge,
+			wantBytes:         10,
+			wantKeysListOrder: []any{"b", "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(100, WithMaxBytes(10, tt.sizeFn), WithInvariantChecks())
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			for _, pair := range tt.addPairs {
+				err = c.Add(pair[0], pair[1], 0)
+			}
+			if tt.replace != nil {
+				err = c.Replace(tt.replace[0], tt.replace[1])
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error, got %v, want %v", err, tt.wantErr)
+			}
+			if c.Bytes() != tt.wantBytes {
+				t.Errorf("unexpected size, got %v, want %v", c.Bytes(), tt.wantBytes)
+			}
+			cmpCacheListOrder(t, c, tt.wantKeysListOrder)
+		})
+	}
+}
+
+func TestWithMaxBytes_UpdateVal(t *testing.T) {
+	policies := map[string]EvictionPolicy{
+		"lru":     PolicyLRU,
+		"fifo":    PolicyFIFO,
+		"sampled": PolicySampled,
+		"slru":    PolicySLRU,
+	}
+	for name, policy := range policies {
+		t.Run(name, func(t *testing.T) {
+			c, err := New(100, WithMaxBytes(10, nil), WithEvictionPolicy(policy), WithInvariantChecks())
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			addItems(t, c, [][]any{{"a", "12345"}, {"b", "12345"}})
+			if _, err := c.UpdateVal("a", "12345678"); err != nil {
+				t.Fatalf("unexpected error, got %v", err)
+			}
+			if got, _ := c.Peek("a"); got != "12345678" {
+				t.Errorf("unexpected value, got %v, want %v", got, "12345678")
+			}
+			if c.Len() != 1 || c.Bytes() != 8 {
+				t.Errorf("unexpected length and size, got %v and %v, want 1 and 8", c.Len(), c.Bytes())
+			}
+		})
+	}
+}
+
+func TestCache_AddWithCost(t *testing.T) {
+	tests := []struct {
+		name              string
+		addCosts          []int64
+		replace           []any
+		wantErr           error
+		wantCost          int64
+		wantKeysListOrder []any
+	}{
+		{
+			name:              "evicts least recently used items until budget is satisfied",
+			addCosts:          []int64{4, 3, 6},
+			wantCost:          9,
+			wantKeysListOrder: []any{"c", "b"},
+		},
+		{
+			name:              "adds items of zero cost",
+			addCosts:          []int64{10, 0, 0},
+			wantCost:          10,
+			wantKeysListOrder: []any{"c", "b", "a"},
+		},
+		{
+			name:              "rejects negative cost",
+			addCosts:          []int64{1, -1},
+			wantErr:           errNegativeCost,
+			wantCost:          1,
+			wantKeysListOrder: []any{"a"},
+		},
+		{
+			name:              "rejects cost bigger than limit",
+			addCosts:          []int64{1, 11},
+			wantErr:           ErrTooLarge,
+			wantCost:          1,
+			wantKeysListOrder: []any{"a"},
+		},
+		{
+			name:              "keeps cost when value is replaced",
+			addCosts:          []int64{4, 6},
+			replace:           []any{"a", "123456789"},
+			wantCost:          10,
+			wantKeysListOrder: []any{"b", "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(100, WithMaxCost(10), WithInvariantChecks())
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			for i, cost := range tt.addCosts {
+				err = c.AddWithCost(string(rune('a'+i)), v, cost, 0)
+			}
+			if tt.replace != nil {
+				err = c.Replace(tt.replace[0], tt.replace[1])
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error, got %v, want %v", err, tt.wantErr)
+			}
+			if c.Cost() != tt.wantCost {
+				t.Errorf("unexpected cost, got %v, want %v", c.Cost(), tt.wantCost)
+			}
+			cmpCacheListOrder(t, c, tt.wantKeysListOrder)
+		})
+	}
+}
+
+func TestCache_UpdateCost(t *testing.T) {
+	tests := []struct {
+		name              string
+		opts              []Option
+		key               any
+		cost              int64
+		wantErr           error
+		wantCost          int64
+		wantKeysListOrder []any
+	}{
+		{
+			name:              "evicts other items until new cost fits",
+			opts:              []Option{WithMaxCost(10)},
+			key:               "a",
+			cost:              6,
+			wantCost:          10,
+			wantKeysListOrder: []any{"c", "a"},
+		},
+		{
+			name:              "releases cost of shrunk item",
+			opts:              []Option{WithMaxCost(10)},
+			key:               "c",
+			cost:              1,
+			wantCost:          6,
+			wantKeysListOrder: []any{"c", "b", "a"},
+		},
+		{
+			name:              "keeps cost bigger than limit",
+			opts:              []Option{WithMaxCost(10)},
+			key:               "a",
+			cost:              11,
+			wantErr:           ErrTooLarge,
+			wantCost:          9,
+			wantKeysListOrder: []any{"c", "b", "a"},
+		},
+		{
+			name:              "rejects negative cost",
+			opts:              []Option{WithMaxCost(10)},
+			key:               "a",
+			cost:              -1,
+			wantErr:           errNegativeCost,
+			wantCost:          9,
+			wantKeysListOrder: []any{"c", "b", "a"},
+		},
+		{
+			name:              "returns error for missing key",
+			opts:              []Option{WithMaxCost(10)},
+			key:               "d",
+			cost:              1,
+			wantErr:           errKeyNotExist,
+			wantCost:          9,
+			wantKeysListOrder: []any{"c", "b", "a"},
+		},
+		{
+			name:              "returns error without cost limit",
+			opts:              []Option{WithMaxBytes(10, nil)},
+			key:               "a",
+			cost:              1,
+			wantErr:           errNotWeighted,
+			wantCost:          9,
+			wantKeysListOrder: []any{"c", "b", "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(100, append(tt.opts, WithInvariantChecks())...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			for i, cost := range []int64{2, 3, 4} {
+				if err := c.AddWithCost(string(rune('a'+i)), v, cost, 0); err != nil {
+					t.Fatalf(err.Error())
+				}
+			}
+			if err := c.UpdateCost(tt.key, tt.cost); !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error, got %v, want %v", err, tt.wantErr)
+			}
+			if c.Cost() != tt.wantCost {
+				t.Errorf("unexpected cost, got %v, want %v", c.Cost(), tt.wantCost)
+			}
+			cmpCacheListOrder(t, c, tt.wantKeysListOrder)
+		})
+	}
+}
+
+func TestWithMaxCost_Add(t *testing.T) {
+	c, err := New(100, WithMaxCost(2))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	addItems(t, c, [][]any{{k, v}, {k + k, v}, {k + k + k, v}})
+	if c.Cost() != 2 {
+		t.Errorf("unexpected cost, got %v, want %v", c.Cost(), 2)
+	}
+	cmpCacheListOrder(t, c, []any{k + k + k, k + k})
+}
+
+func TestCache_CostReport(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		n    int
+		want CostReport
+	}{
+		{
+			name: "reports costliest items and cost of namespaces",
+			opts: []Option{WithMaxCost(100), WithNamespace(PrefixNamespace(":"))},
+			n:    2,
+			want: CostReport{
+				Total:      15,
+				Max:        100,
+				Namespaces: map[string]int64{"a": 9, "b": 6},
+				Top:        []CostEntry{{Key: "b:1", Namespace: "b", Cost: 6}, {Key: "a:2", Namespace: "a", Cost: 5}},
+			},
+		},
+		{
+			name: "reports no items when n is zero",
+			opts: []Option{WithMaxCost(100), WithNamespace(PrefixNamespace(":"))},
+			want: CostReport{Total: 15, Max: 100, Namespaces: map[string]int64{"a": 9, "b": 6}},
+		},
+		{
+			name: "reports nothing without cost limit",
+			n:    2,
+			want: CostReport{Namespaces: map[string]int64{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(10, tt.opts...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			for key, cost := range map[string]int64{"a:1": 4, "a:2": 5, "b:1": 6} {
+				if err := c.AddWithCost(key, v, cost, 0); err != nil {
+					t.Fatalf(err.Error())
+				}
+			}
+			if got := c.CostReport(tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected report, got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	tests := []struct {
+		name            string
+		cost            int64
+		opts            []CallOption
+		wantReport      AddReport
+		wantUtilization float64
+	}{
+		{
+			name:            "reports evicted items and utilization",
+			cost:            6,
+			wantReport:      AddReport{Evicted: 1, EvictedCost: 4, Cost: 9, Max: 10},
+			wantUtilization: 0.9,
+		},
+		{
+			name:            "reports no evictions when item fits",
+			cost:            2,
+			wantReport:      AddReport{Cost: 9, Max: 10},
+			wantUtilization: 0.9,
+		},
+		{
+			name: "keeps report when nothing is stored",
+			cost: 6,
+			opts: []CallOption{Context(NoStore(context.Background()))},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(100, WithMaxCost(10))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			for i, cost := range []int64{4, 3} {
+				if err := c.AddWithCost(string(rune('a'+i)), v, cost, 0); err != nil {
+					t.Fatalf(err.Error())
+				}
+			}
+			var r AddReport
+			if err := c.AddWithCost(k, v, tt.cost, 0, append(tt.opts, Report(&r))...); err != nil {
+				t.Fatalf(err.Error())
+			}
+			if r != tt.wantReport {
+				t.Errorf("unexpected report, got %+v, want %+v", r, tt.wantReport)
+			}
+			if got := r.Utilization(); got != tt.wantUtilization {
+				t.Errorf("unexpected utilization, got %v, want %v", got, tt.wantUtilization)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// SlowOp describes an operation that took longer than the threshold set with
+// WithSlowOpThreshold.
+type SlowOp struct {
+	// Op names the slow operation: "lock wait" and "rlock wait" for waiting
+	// for the lock of the cache, "lock hold" for holding it, "admit",
+	// "validate", "repair", "evict" and "refresh" for the functions set with
+	// WithAdmitFunc, WithValidator, WithReadRepair, WithOnEvict and
+	// WithRefresh, and "load" for the loaders passed to GetOrLoad.
+	Op string
+
+	// Duration is how long the operation took.
+	Duration time.Duration
+
+	// Stack is the stack trace of the goroutine that waited for or held the
+	// lock. For "lock hold", it is the stack of the lock holder when it
+	// released the lock, which shows the operation that stalled the cache.
+	// It is nil for functions.
+	Stack []byte
+}
+
+// WithSlowOpThreshold sets the function called with the operations taking at
+// least d, to diagnose stalls in production. Waiting for and holding the lock
+// of the cache, as well as the functions passed to the cache like the
+// validator, are measured. fn is called in a new goroutine, so it doesn't
+// delay the cache and may call its methods.
+func WithSlowOpThreshold(d time.Duration, fn func(SlowOp)) Option {
+	return func(c *Cache) {
+		c.slowAfter = d
+		c.slowFn = fn
+	}
+}
+
+// reportSlow calls the slow operation function if the operation started at
+// start took at least the threshold.
+func (c *Cache) reportSlow(op string, start time.Time, stack bool) {
+	d := time.Since(start)
+	if d < c.slowAfter {
+		return
+	}
+	s := SlowOp{Op: op, Duration: d}
+	if stack {
+		s.Stack = debug.Stack()
+	}
+	go c.slowFn(s)
+}
+
+// timeFuncs wraps the functions passed to the cache to measure them.
+func (c *Cache) timeFuncs() {
+	if admit := c.admit; admit != nil {
+		c.admit = func(key, val interface{}, exp time.Duration) bool {
+			defer c.reportSlow("admit", time.Now(), false)
+			return admit(key, val, exp)
+		}
+	}
+	if validator := c.validator; validator != nil {
+		c.validator = func(key, val interface{}) error {
+			defer c.reportSlow("validate", time.Now(), false)
+			return validator(key, val)
+		}
+	}
+	if repair := c.repair; repair != nil {
+		c.repair = func(stale Item) (interface{}, time.Duration, bool) {
+			defer c.reportSlow("repair", time.Now(), false)
+			return repair(stale)
+		}
+	}
+	if onEvict := c.onEvict; onEvict != nil {
+		c.onEvict = func(key, val interface{}) {
+			defer c.reportSlow("evict", time.Now(), false)
+			onEvict(key, val)
+		}
+	}
+	if refresh := c.refresh; refresh != nil {
+		c.refresh = func(key interface{}) (interface{}, error) {
+			defer c.reportSlow("refresh", time.Now(), false)
+			return refresh(key)
+		}
+	}
+}
+
+// timedLocker is a locker that reports slow waits for the lock and slow
+// holders of the write lock.
+type timedLocker struct {
+	locker
+	c *Cache
+
+	// locked is when the write lock is acquired. It is guarded by the lock.
+	locked time.Time
+}
+
+// Lock acquires the write lock and reports if it took too long.
+func (l *timedLocker) Lock() {
+	start := time.Now()
+	l.locker.Lock()
+	l.c.reportSlow("lock wait", start, true)
+	l.locked = time.Now()
+}
+
+// Unlock releases the write lock and reports if it was held too long.
+func (l *timedLocker) Unlock() {
+	locked := l.locked
+	l.c.reportSlow("lock hold", locked, true)
+	l.locker.Unlock()
+}
+
+// RLock acquires the read lock and reports if it took too long.
+func (l *timedLocker) RLock() {
+	start := time.Now()
+	l.locker.RLock()
+	l.c.reportSlow("rlock wait", start, true)
+}
@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithSlowOpThreshold(t *testing.T) {
+	const delay = 20 * time.Millisecond
+	tests := []struct {
+		name      string
+		opts      []Option
+		run       func(c *Cache)
+		wantOps   []string
+		wantStack bool
+	}{
+		{
+			name: "reports slow validator",
+			opts: []Option{WithValidator(func(key, val any) error {
+				time.Sleep(delay)
+				return nil
+			})},
+			run:     func(c *Cache) { c.Add(k, v, 0) },
+			wantOps: []string{"validate"},
+		},
+		{
+			name: "reports slow admission function",
+			opts: []Option{WithAdmitFunc(func(key, val any, exp time.Duration) bool {
+				time.Sleep(delay)
+				return true
+			})},
+			run:     func(c *Cache) { c.Add(k, v, 0) },
+			wantOps: []string{"admit"},
+		},
+		{
+			name: "reports slow loader",
+			run: func(c *Cache) {
+				c.GetOrLoad(k, 0, func(key any) (any, error) {
+					time.Sleep(delay)
+					return v, nil
+				})
+			},
+			wantOps: []string{"load"},
+		},
+		{
+			name: "reports slow eviction callback",
+			opts: []Option{WithOnEvict(func(key, val any) {
+				time.Sleep(delay)
+			})},
+			run: func(c *Cache) {
+				c.Add(k, v, 0)
+				c.Add(k+k, v, 0)
+			},
+			wantOps: []string{"evict"},
+		},
+		{
+			name: "reports slow refresh function",
+			opts: []Option{WithRefresh(func(key any) (any, error) {
+				time.Sleep(delay)
+				return v, nil
+			})},
+			run: func(c *Cache) {
+				c.Add(k, v, 0, SoftTTL(time.Nanosecond))
+				time.Sleep(time.Millisecond)
+				c.Get(k)
+			},
+			wantOps: []string{"refresh"},
+		},
+		{
+			name: "reports slow lock holder and waiter",
+			run: func(c *Cache) {
+				c.mu.Lock()
+				go func() {
+					time.Sleep(delay)
+					c.mu.Unlock()
+				}()
+				c.Len()
+			},
+			wantOps:   []string{"lock hold", "rlock wait"},
+			wantStack: true,
+		},
+		{
+			name:    "does not report fast operations",
+			run:     func(c *Cache) { c.Add(k, v, 0) },
+			wantOps: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := make(chan SlowOp, 10)
+			opts := append(tt.opts, WithSlowOpThreshold(delay/2, func(op SlowOp) {
+				ops <- op
+			}))
+			c, err := New(1, opts...)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			tt.run(c)
+
+			got := make(map[string]SlowOp)
+			for range tt.wantOps {
+				select {
+				case op := <-ops:
+					got[op.Op] = op
+				case <-time.After(time.Second):
+				}
+			}
+			for _, name := range tt.wantOps {
+				op, ok := got[name]
+				if !ok {
+					t.Errorf("expected %q to be reported, got %v", name, got)
+					continue
+				}
+				if op.Duration < delay/2 {
+					t.Errorf("unexpected duration of %q, got %v", name, op.Duration)
+				}
+				if (op.Stack != nil) != tt.wantStack {
+					t.Errorf("unexpected stack of %q, got %s", name, op.Stack)
+				}
+			}
+			select {
+			case op := <-ops:
+				t.Errorf("unexpected report %+v", op)
+			case <-time.After(delay):
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package cache
+
+import (
+	"container/list"
+	"time"
+)
+
+// Stats holds the counters of cache operations.
+type Stats struct {
+	// Hits is the number of Get calls that found the key.
+	Hits uint64
+
+	// Misses is the number of Get calls that did not find the key.
+	Misses uint64
+
+	// MissesExpired, MissesEvicted and MissesInvalidated count the misses on
+	// the keys whose items are removed since they are expired, evicted, or
+	// invalidated by BumpNamespace, Remove or RemoveOldest.
+	// MissesNeverPresent counts the other misses, see
+	// WithMissClassification. They are counted only if the cache is created
+	// with WithMissClassification.
+	MissesExpired      uint64
+	MissesEvicted      uint64
+	MissesInvalidated  uint64
+	MissesNeverPresent uint64
+
+	// Evictions is the number of items removed to make room for new items,
+	// either because the capacity is full, a quota is reached or the cache is
+	// resized. Items removed explicitly are not counted.
+	Evictions uint64
+
+	// Loads is the number of values loaded successfully by GetOrLoad.
+	Loads uint64
+
+	// LoadErrors is the number of GetOrLoad calls whose loader returned
+	// error.
+	LoadErrors uint64
+
+	// LoadTime is the total time spent by the loaders of GetOrLoad.
+	LoadTime time.Duration
+
+	// LoadsCoalesced is the number of GetOrLoad calls which received the
+	// result of the loader of another call of the same key instead of
+	// calling their own, see GetOrLoad. Together with Loads and LoadErrors,
+	// it tells how many misses the origin is spared.
+	LoadsCoalesced uint64
+
+	// StaleHits is the number of Get calls which found an item whose soft
+	// TTL passed, see SoftTTL. They are counted in Hits as well.
+	StaleHits uint64
+
+	// DistinctKeys is the estimated number of distinct keys retrieved with
+	// Get in the latest complete window set with WithCardinality. It is 0
+	// for namespaces, and if the window of the estimate ended more than a
+	// window ago.
+	DistinctKeys uint64
+}
+
+// Stats returns the counters of the whole cache.
+func (c *Cache) Stats() Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s := c.stats
+	s.DistinctKeys = c.card.previous(time.Now().UnixNano())
+	return s
+}
+
+// HitRatio returns the ratio of the Get calls that found the key. It returns
+// 1 if there are no Get calls, like HitRate of CacheStats.
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 1
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// WithNamespaceStats keeps the counters of the given namespaces, see
+// NamespaceStats. The counters of the namespaces limited by
+// WithNamespaceQuota are kept as well.
+func WithNamespaceStats(ns ...string) Option {
+	return func(c *Cache) {
+		for _, n := range ns {
+			c.nsStats[n] = &Stats{}
+		}
+	}
+}
+
+// NamespaceStats returns the counters of the given namespace. Namespaces are
+// derived from keys by the function set with WithNamespace. Counters are kept
+// only for the namespaces registered with WithNamespaceStats or limited with
+// WithNamespaceQuota, so keys of arbitrary namespaces don't grow them without
+// bound. They count from the creation of the cache, whether the namespace has
+// items or not. It returns empty counters for other namespaces.
+func (c *Cache) NamespaceStats(ns string) Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if s, ok := c.nsStats[ns]; ok {
+		return *s
+	}
+	return Stats{}
+}
+
+// SourceCounts returns the number of items in cache by the source they are
+// tagged with, see Source. Items added without a source are counted under "".
+// Expired and invalidated items are not counted.
+func (c *Cache) SourceCounts() map[string]int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	counts := make(map[string]int)
+	for e := c.lst.Front(); e != nil; e = e.Next() {
+		if item := mustItem(e); !c.stale(item) && !item.Expired() {
+			counts[item.Source]++
+		}
+	}
+	return counts
+}
+
+// namespaceStats returns the counters of the namespace to be updated. It
+// returns nil if the counters of the namespace are not kept.
+func (c *Cache) namespaceStats(ns string) *Stats {
+	return c.nsStats[ns]
+}
+
+// hit records a Get call which found the key.
+func (c *Cache) hit(key interface{}) {
+	c.stats.Hits++
+	if ns := c.namespaceStats(c.namespace(key)); ns != nil {
+		ns.Hits++
+	}
+	c.hot.observe(key, time.Now().UnixNano())
+	c.card.observe(key, time.Now().UnixNano())
+}
+
+// staleHit records a Get call which found an item past its soft TTL, besides
+// the hit recorded by access.
+func (c *Cache) staleHit(key interface{}) {
+	c.stats.StaleHits++
+	if ns := c.namespaceStats(c.namespace(key)); ns != nil {
+		ns.StaleHits++
+	}
+}
+
+// miss records a Get call which did not find the key.
+func (c *Cache) miss(key interface{}) {
+	ns := c.namespaceStats(c.namespace(key))
+	c.stats.Misses++
+	if ns != nil {
+		ns.Misses++
+	}
+	c.classify(key, &c.stats, ns)
+	c.card.observe(key, time.Now().UnixNano())
+	c.tuner.miss(key, c.len)
+}
+
+// evict removes the element to make room for new items and records it.
+func (c *Cache) evict(e *list.Element, reason EvictionReason) {
+	item := mustItem(e)
+	ns := c.namespace(item.Key)
+	c.stats.Evictions++
+	if s := c.namespaceStats(ns); s != nil {
+		s.Evictions++
+	}
+	c.evictedCost += item.Cost
+	c.tuner.evicted(item.Key)
+	c.audit.record(item, ns, reason)
+	c.lifetimes.removed(item, true)
+	c.tombs.push(item.Key, missEvicted)
+	c.unlink(e)
+}
+
+// evictOldest evicts the least recently used item, or the item picked by the
+// eviction policy. It returns false if the cache is empty.
+func (c *Cache) evictOldest(reason EvictionReason) bool {
+	e := c.victim(nil)
+	if e == nil {
+		return false
+	}
+	c.evict(e, reason)
+	return true
+}
+
+// CacheStats mirrors the CacheStats of Guava and Caffeine, to ease porting
+// dashboards and SLOs of JVM services. The load counters are updated by
+// GetOrLoad.
+type CacheStats struct {
+	HitCount           uint64
+	MissCount          uint64
+	LoadSuccessCount   uint64
+	LoadExceptionCount uint64
+	TotalLoadTime      time.Duration
+	EvictionCount      uint64
+}
+
+// CacheStats returns the counters of the whole cache as CacheStats.
+func (c *Cache) CacheStats() CacheStats {
+	s := c.Stats()
+	return CacheStats{
+		HitCount:           s.Hits,
+		MissCount:          s.Misses,
+		LoadSuccessCount:   s.Loads,
+		LoadExceptionCount: s.LoadErrors,
+		TotalLoadTime:      s.LoadTime,
+		EvictionCount:      s.Evictions,
+	}
+}
+
+// RequestCount returns the number of Get calls.
+func (s CacheStats) RequestCount() uint64 {
+	return s.HitCount + s.MissCount
+}
+
+// HitRate returns the ratio of Get calls that found the key. It returns 1 if
+// there are no Get calls, like Guava and Caffeine.
+func (s CacheStats) HitRate() float64 {
+	if s.RequestCount() == 0 {
+		return 1
+	}
+	return float64(s.HitCount) / float64(s.RequestCount())
+}
+
+// MissRate returns the ratio of Get calls that did not find the key. It
+// returns 0 if there are no Get calls.
+func (s CacheStats) MissRate() float64 {
+	if s.RequestCount() == 0 {
+		return 0
+	}
+	return float64(s.MissCount) / float64(s.RequestCount())
+}
+
+// LoadCount returns the number of loads, either successful or failed.
+func (s CacheStats) LoadCount() uint64 {
+	return s.LoadSuccessCount + s.LoadExceptionCount
+}
+
+// LoadExceptionRate returns the ratio of failed loads. It returns 0 if there
+// are no loads.
+func (s CacheStats) LoadExceptionRate() float64 {
+	if s.LoadCount() == 0 {
+		return 0
+	}
+	return float64(s.LoadExceptionCount) / float64(s.LoadCount())
+}
+
+// AverageLoadPenalty returns the average time spent loading values. It
+// returns 0 if there are no loads.
+func (s CacheStats) AverageLoadPenalty() time.Duration {
+	if s.LoadCount() == 0 {
+		return 0
+	}
+	return s.TotalLoadTime / time.Duration(s.LoadCount())
+}
+
+// Minus returns the difference of the counters, so the counters of an interval
+// can be computed from two readings. Counters don't go below zero.
+func (s CacheStats) Minus(other CacheStats) CacheStats {
+	sub := func(a, b uint64) uint64 {
+		if a < b {
+			return 0
+		}
+		return a - b
+	}
+	load := s.TotalLoadTime - other.TotalLoadTime
+	if load < 0 {
+		load = 0
+	}
+	return CacheStats{
+		HitCount:           sub(s.HitCount, other.HitCount),
+		MissCount:          sub(s.MissCount, other.MissCount),
+		LoadSuccessCount:   sub(s.LoadSuccessCount, other.LoadSuccessCount),
+		LoadExceptionCount: sub(s.LoadExceptionCount, other.LoadExceptionCount),
+		TotalLoadTime:      load,
+		EvictionCount:      sub(s.EvictionCount, other.EvictionCount),
+	}
+}
+
+// Plus returns the sum of the counters.
+func (s CacheStats) Plus(other CacheStats) CacheStats {
+	return CacheStats{
+		HitCount:           s.HitCount + other.HitCount,
+		MissCount:          s.MissCount + other.MissCount,
+		LoadSuccessCount:   s.LoadSuccessCount + other.LoadSuccessCount,
+		LoadExceptionCount: s.LoadExceptionCount + other.LoadExceptionCount,
+		TotalLoadTime:      s.TotalLoadTime + other.TotalLoadTime,
+		EvictionCount:      s.EvictionCount + other.EvictionCount,
+	}
+}
@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCache_Stats(t *testing.T) {
+	tests := []struct {
+		name      string
+		capacity  int
+		addPairs  [][]any
+		getKeys   []any
+		resize    int
+		wantStats Stats
+	}{
+		{
+			name:      "counts misses on empty cache",
+			capacity:  1,
+			addPairs:  [][]any{},
+			getKeys:   []any{k, k + k},
+			wantStats: Stats{Misses: 2},
+		},
+		{
+			name:      "counts hits and misses",
+			capacity:  2,
+			addPairs:  [][]any{{k, v}, {k + k, v + v}},
+			getKeys:   []any{k, k, "nonexistent"},
+			wantStats: Stats{Hits: 2, Misses: 1},
+		},
+		{
+			name:      "counts evictions when capacity is full",
+			capacity:  1,
+			addPairs:  [][]any{{k, v}, {k + k, v + v}, {k + k + k, v + v + v}},
+			getKeys:   []any{k},
+			wantStats: Stats{Misses: 1, Evictions: 2},
+		},
+		{
+			name:      "counts evictions when cache is resized",
+			capacity:  3,
+			addPairs:  [][]any{{k, v}, {k + k, v + v}, {k + k + k, v + v + v}},
+			resize:    1,
+			wantStats: Stats{Evictions: 2},
+		},
+	}
+	for _, tt := range tests {
+		c := createCache(t, tt.capacity)
+		addItems(t, c, tt.addPairs)
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range tt.getKeys {
+				c.Get(key)
+			}
+			if tt.resize != 0 {
+				c.Resize(tt.resize)
+			}
+			if got := c.Stats(); got != tt.wantStats {
+				t.Errorf("unexpected stats, got %+v, want %+v", got, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestStats_HitRatio(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats Stats
+		want  float64
+	}{
+		{name: "returns one without gets", stats: Stats{}, want: 1},
+		{name: "returns ratio of hits", stats: Stats{Hits: 3, Misses: 1, Evictions: 5}, want: 0.75},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.HitRatio(); got != tt.want {
+				t.Errorf("Stats.HitRatio() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCache_NamespaceStats(t *testing.T) {
+	c, err := New(3, WithNamespace(PrefixNamespace(":")), WithNamespaceQuota("a", 1), WithNamespaceStats("b"))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	addItems(t, c, [][]any{{"a:1", v}, {"b:1", v}, {"a:2", v}})
+	for _, key := range []any{"a:1", "a:2", "b:1", "b:2", "b:3"} {
+		c.Get(key)
+	}
+
+	tests := []struct {
+		name      string
+		ns        string
+		wantStats Stats
+	}{
+		{
+			name:      "returns counters of namespace with quota evictions",
+			ns:        "a",
+			wantStats: Stats{Hits: 1, Misses: 1, Evictions: 1},
+		},
+		{
+			name:      "returns counters of namespace without evictions",
+			ns:        "b",
+			wantStats: Stats{Hits: 1, Misses: 2},
+		},
+		{
+			name:      "returns empty counters of unknown namespace",
+			ns:        "c",
+			wantStats: Stats{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.NamespaceStats(tt.ns); got != tt.wantStats {
+				t.Errorf("unexpected stats, got %+v, want %+v", got, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestCache_NamespaceStatsBounded(t *testing.T) {
+	c, err := New(3, WithNamespace(PrefixNamespace(":")), WithNamespaceStats("a", "b"))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	c.Get("b:1")
+	addItems(t, c, [][]any{{"a:1", v}, {"b:1", v}})
+	for i := 0; i < 1000; i++ {
+		c.Get(fmt.Sprintf("miss%d:1", i))
+	}
+	if len(c.nsStats) != 2 {
+		t.Errorf("expected counters of unregistered namespaces not to be kept, got %v namespaces", len(c.nsStats))
+	}
+	if got := c.Stats().Misses; got != 1001 {
+		t.Errorf("unexpected misses, got %v, want %v", got, 1001)
+	}
+
+	c.Get("b:1")
+	c.Remove("b:1")
+	c.Compact()
+	if got, want := c.NamespaceStats("b"), (Stats{Hits: 1, Misses: 1}); got != want {
+		t.Errorf("unexpected stats of empty namespace, got %+v, want %+v", got, want)
+	}
+	if got, want := c.NamespaceStats("miss1"), (Stats{}); got != want {
+		t.Errorf("unexpected stats of unregistered namespace, got %+v, want %+v", got, want)
+	}
+}
+
+func TestCache_CacheStats(t *testing.T) {
+	c := createCache(t, 1)
+	addItems(t, c, [][]any{{k, v}, {k + k, v + v}})
+	c.Get(k)
+	c.Get(k + k)
+	want := CacheStats{HitCount: 1, MissCount: 1, EvictionCount: 1}
+	if got := c.CacheStats(); got != want {
+		t.Errorf("unexpected stats, got %+v, want %+v", got, want)
+	}
+}
+
+func TestCacheStats_Rates(t *testing.T) {
+	tests := []struct {
+		name            string
+		stats           CacheStats
+		wantRequests    uint64
+		wantHitRate     float64
+		wantMissRate    float64
+		wantLoadPenalty time.Duration
+		wantLoadErrRate float64
+	}{
+		{
+			name:         "returns full hit rate without requests",
+			stats:        CacheStats{},
+			wantRequests: 0,
+			wantHitRate:  1,
+			wantMissRate: 0,
+		},
+		{
+			name:            "computes rates from counters",
+			stats:           CacheStats{HitCount: 3, MissCount: 1, LoadSuccessCount: 3, LoadExceptionCount: 1, TotalLoadTime: 4 * time.Second},
+			wantRequests:    4,
+			wantHitRate:     0.75,
+			wantMissRate:    0.25,
+			wantLoadPenalty: time.Second,
+			wantLoadErrRate: 0.25,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.stats
+			if s.RequestCount() != tt.wantRequests || s.HitRate() != tt.wantHitRate || s.MissRate() != tt.wantMissRate {
+				t.Errorf("unexpected request rates of %+v", s)
+			}
+			if s.AverageLoadPenalty() != tt.wantLoadPenalty || s.LoadExceptionRate() != tt.wantLoadErrRate {
+				t.Errorf("unexpected load rates of %+v", s)
+			}
+		})
+	}
+}
+
+func TestCacheStats_MinusPlus(t *testing.T) {
+	a := CacheStats{HitCount: 5, MissCount: 2, TotalLoadTime: time.Second, EvictionCount: 1}
+	b := CacheStats{HitCount: 3, MissCount: 4, TotalLoadTime: 2 * time.Second}
+	if got, want := a.Minus(b), (CacheStats{HitCount: 2, EvictionCount: 1}); got != want {
+		t.Errorf("CacheStats.Minus() = %+v, want %+v", got, want)
+	}
+	if got, want := a.Plus(b), (CacheStats{HitCount: 8, MissCount: 6, TotalLoadTime: 3 * time.Second, EvictionCount: 1}); got != want {
+		t.Errorf("CacheStats.Plus() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"path"
+	"time"
+)
+
+// TTLRule sets the expiration duration of the items whose key matches a
+// pattern.
+type TTLRule struct {
+	// Pattern is matched against string keys with the syntax of path.Match,
+	// e.g. "user:*". Malformed patterns match no keys.
+	Pattern string
+
+	// TTL is the expiration duration of the matching items.
+	TTL time.Duration
+}
+
+// WithTTLRules sets the rules that give items a default expiration duration
+// based on their key, so call sites don't have to specify it. The rules apply
+// to Add and FromMap calls with an expiration duration of 0, the first
+// matching rule wins. Items whose key matches no rule get the duration set
+// with WithDefaultTTL, they never expire if it is not set.
+func WithTTLRules(rules []TTLRule) Option {
+	return func(c *Cache) {
+		c.ttlRules = make([]TTLRule, len(rules))
+		copy(c.ttlRules, rules)
+	}
+}
+
+// ttl returns the expiration duration of the item with the given key. The
+// given duration is returned unless it is 0, in which case the first matching
+// rule or the default expiration duration is used.
+func (c *Cache) ttl(key interface{}, exp time.Duration) time.Duration {
+	if exp != 0 {
+		return exp
+	}
+	if s, ok := key.(string); ok {
+		for _, rule := range c.ttlRules {
+			if matched, _ := path.Match(rule.Pattern, s); matched {
+				return rule.TTL
+			}
+		}
+	}
+	return c.defaultTTL
+}
+
+// ttlOf is ttl for callers not holding the lock of the cache.
+func (c *Cache) ttlOf(key interface{}, exp time.Duration) time.Duration {
+	if exp != 0 {
+		return exp
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ttl(key, exp)
+}
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithTTLRules(t *testing.T) {
+	rules := []TTLRule{
+		{Pattern: "user:*", TTL: 5 * time.Minute},
+		{Pattern: "geo:*", TTL: 24 * time.Hour},
+		{Pattern: "*", TTL: time.Hour},
+		{Pattern: "[", TTL: time.Second},
+	}
+	tests := []struct {
+		name string
+		key  any
+		exp  time.Duration
+		want time.Duration
+	}{
+		{
+			name: "uses first matching rule",
+			key:  "user:42",
+			want: 5 * time.Minute,
+		},
+		{
+			name: "uses later rule if earlier ones do not match",
+			key:  "geo:ist",
+			want: 24 * time.Hour,
+		},
+		{
+			name: "keeps explicit expiration duration",
+			key:  "user:42",
+			exp:  time.Second,
+			want: time.Second,
+		},
+		{
+			name: "does not match non-string keys",
+			key:  42,
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(1, WithTTLRules(rules))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if got := c.ttl(tt.key, tt.exp); got != tt.want {
+				t.Errorf("cache.ttl() = %v, want %v", got, tt.want)
+			}
+
+			if err := c.Add(tt.key, v, tt.exp); err != nil {
+				t.Fatalf(err.Error())
+			}
+			item, _ := c.PeekWithInfo(tt.key)
+			if tt.want == 0 && item.Expiration != 0 {
+				t.Errorf("expected item to never expire, got %v", item.ExpiresAt())
+			}
+			if got := item.RemainingTTL(); tt.want != 0 && (got > tt.want || got < tt.want-time.Minute) {
+				t.Errorf("unexpected remaining TTL, got %v, want about %v", got, tt.want)
+			}
+		})
+	}
+}
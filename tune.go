@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"container/list"
+	"fmt"
+)
+
+// tuneTolerance is how much lower than the best estimated hit ratio the hit
+// ratio of a recommended capacity may be.
+const tuneTolerance = 0.01
+
+// tuner records the stack distances of the accesses to estimate the hit
+// ratio of other capacities. The stack distance of an access is the smallest
+// capacity that would have made it a hit. Hits know their distance from their
+// position in the list. Misses on recently evicted keys know it from their
+// position in the ghost list, which keeps the keys of evicted items.
+type tuner struct {
+	min, max int
+
+	// ghost keeps the keys of up to max evicted items.
+	ghost *ghostList
+
+	// counts holds the number of accesses with each stack distance up to
+	// max. Index 0 is unused.
+	counts []uint64
+
+	// total is the number of all accesses, including the ones whose stack
+	// distance is unknown or more than max.
+	total uint64
+}
+
+// CapacityRecommendation is the capacity recommended by the autotuner, with
+// the data it is based on.
+type CapacityRecommendation struct {
+	// Capacity is the recommended capacity.
+	Capacity int
+
+	// Current is the capacity of the cache when the recommendation is made.
+	Current int
+
+	// HitRatio is the hit ratio of Get calls observed with the current
+	// capacity.
+	HitRatio float64
+
+	// EstimatedHitRatio is the hit ratio estimated for the recommended
+	// capacity.
+	EstimatedHitRatio float64
+
+	// Samples is the number of Get calls the estimate is based on.
+	Samples uint64
+}
+
+// String returns the recommendation in a form suitable for logging.
+func (r CapacityRecommendation) String() string {
+	return fmt.Sprintf("capacity %d -> %d: hit ratio %.3f -> %.3f estimated from %d gets",
+		r.Current, r.Capacity, r.HitRatio, r.EstimatedHitRatio, r.Samples)
+}
+
+// WithCapacityTuning enables recording the data for recommending a capacity
+// between min and max, see RecommendCapacity. The keys of up to max evicted
+// items are kept in a ghost list to estimate how a bigger capacity would
+// perform. Recording makes Get slower, since the position of hits in the
+// access order has to be found.
+func WithCapacityTuning(min, max int) Option {
+	return func(c *Cache) {
+		if min < 1 {
+			min = 1
+		}
+		if max < min {
+			max = min
+		}
+		c.tuner = &tuner{
+			min:    min,
+			max:    max,
+			ghost:  newGhostList(max),
+			counts: make([]uint64, max+1),
+		}
+	}
+}
+
+// RecommendCapacity returns the smallest capacity between the bounds set with
+// WithCapacityTuning whose estimated hit ratio is within one percentage point
+// of the best one, based on the Get calls since the cache is created or the
+// last call of AutotuneCapacity. It returns the current capacity if the cache
+// is not created with WithCapacityTuning or no Get call is recorded.
+func (c *Cache) RecommendCapacity() CapacityRecommendation {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tuner.recommend(c.cap)
+}
+
+// AutotuneCapacity resizes the cache to the recommended capacity, see
+// RecommendCapacity, and starts recording anew. fn is called with the
+// recommendation if it is not nil, so the decision can be logged. See
+// ScheduleAutotune to run it periodically.
+func (c *Cache) AutotuneCapacity(fn func(CapacityRecommendation)) {
+	var r CapacityRecommendation
+	func() {
+		defer c.resizeChildren()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		r = c.tuner.recommend(c.cap)
+		if r.Capacity != c.cap {
+			c.resize(r.Capacity)
+		}
+		c.tuner.reset()
+	}()
+	if fn != nil {
+		fn(r)
+	}
+}
+
+// ScheduleAutotune calls AutotuneCapacity on a recurring schedule described
+// by spec. See ScheduleClear for the format of spec.
+//
+// It returns a stop function which cancels the schedule. Calling stop more
+// than once is safe.
+func (c *Cache) ScheduleAutotune(spec string, fn func(CapacityRecommendation)) (stop func(), err error) {
+	return c.runOnSchedule(spec, "autotune", func() { c.AutotuneCapacity(fn) })
+}
+
+// hit records a hit at the given position of the access order, 0 being the
+// most recently used item.
+func (t *tuner) hit(pos int) {
+	if t == nil {
+		return
+	}
+	t.total++
+	if d := pos + 1; d <= t.max {
+		t.counts[d]++
+	}
+}
+
+// miss records a miss of the key while the cache holds n items.
+func (t *tuner) miss(key interface{}, n int) {
+	if t == nil {
+		return
+	}
+	t.total++
+	pos, ok := t.ghost.take(key)
+	if !ok {
+		return
+	}
+	if d := n + pos + 1; d <= t.max {
+		t.counts[d]++
+	}
+}
+
+// evicted records the eviction of the key.
+func (t *tuner) evicted(key interface{}) {
+	if t == nil {
+		return
+	}
+	t.ghost.push(key, missEvicted)
+}
+
+// reset drops the recorded accesses. The ghost list is kept, since it
+// reflects the evictions rather than the accesses.
+func (t *tuner) reset() {
+	if t == nil {
+		return
+	}
+	for i := range t.counts {
+		t.counts[i] = 0
+	}
+	t.total = 0
+}
+
+// recommend returns the recommended capacity for a cache of the given
+// capacity.
+func (t *tuner) recommend(cap int) CapacityRecommendation {
+	r := CapacityRecommendation{Capacity: cap, Current: cap}
+	if t == nil || t.total == 0 {
+		return r
+	}
+	r.Samples = t.total
+
+	// hits[x] is the number of accesses that hit with capacity x.
+	hits := make([]uint64, t.max+1)
+	for d := 1; d <= t.max; d++ {
+		hits[d] = hits[d-1] + t.counts[d]
+	}
+	ratio := func(x int) float64 {
+		if x > t.max {
+			x = t.max
+		}
+		return float64(hits[x]) / float64(t.total)
+	}
+	r.HitRatio = ratio(cap)
+
+	best := ratio(t.max)
+	for x := t.min; x <= t.max; x++ {
+		if ratio(x) >= best-tuneTolerance {
+			r.Capacity = x
+			r.EstimatedHitRatio = ratio(x)
+			break
+		}
+	}
+	return r
+}
+
+// position returns the position of the element in the access order, 0 being
+// the most recently used item.
+func (c *Cache) position(e *list.Element) int {
+	pos := 0
+	for f := c.lst.Front(); f != e; f = f.Next() {
+		pos++
+	}
+	return pos
+}
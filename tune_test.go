@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestCache_RecommendCapacity(t *testing.T) {
+	tests := []struct {
+		name         string
+		capacity     int
+		min, max     int
+		keys         []any
+		rounds       int
+		wantCapacity int
+	}{
+		{
+			name:         "grows capacity to fit cyclic working set",
+			capacity:     2,
+			min:          1,
+			max:          8,
+			keys:         []any{1, 2, 3},
+			rounds:       20,
+			wantCapacity: 3,
+		},
+		{
+			name:         "shrinks capacity to hot working set",
+			capacity:     8,
+			min:          1,
+			max:          8,
+			keys:         []any{1, 2},
+			rounds:       20,
+			wantCapacity: 2,
+		},
+		{
+			name:         "recommends minimum when no capacity within bounds helps",
+			capacity:     2,
+			min:          1,
+			max:          4,
+			keys:         []any{1, 2, 3, 4, 5, 6},
+			rounds:       20,
+			wantCapacity: 1,
+		},
+		{
+			name:         "keeps current capacity without samples",
+			capacity:     5,
+			min:          1,
+			max:          8,
+			keys:         []any{},
+			wantCapacity: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(tt.capacity, WithCapacityTuning(tt.min, tt.max))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			for i := 0; i < tt.rounds; i++ {
+				for _, key := range tt.keys {
+					if _, found := c.Get(key); !found {
+						c.Add(key, v, 0)
+					}
+				}
+			}
+			r := c.RecommendCapacity()
+			if r.Capacity != tt.wantCapacity {
+				t.Errorf("unexpected recommendation, got %v, want capacity %v", r, tt.wantCapacity)
+			}
+
+			var got CapacityRecommendation
+			c.AutotuneCapacity(func(r CapacityRecommendation) { got = r })
+			if got != r || c.Cap() != tt.wantCapacity {
+				t.Errorf("unexpected autotune, got %v with capacity %v, want %v", got, c.Cap(), r)
+			}
+			if c.RecommendCapacity().Samples != 0 {
+				t.Errorf("expected autotune to reset samples")
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// WithValueCodecs sets the codecs applied to the values, e.g. to compress,
+// encrypt and checksum them. Values must be []byte. Codecs are applied in the
+// given order by Add, FromMap, Replace and UpdateVal, and in reverse order by
+// Get, GetOrLoad, Peek, PeekWithInfo, Range, ToMap and RemoveOldest, e.g.
+// WithValueCodecs(GzipCodec(), aesCodec, ChecksumCodec()) compresses, then
+// encrypts and then checksums values. Values that can't be decoded are
+// reported as not found by Get and Peek and skipped by Range and ToMap.
+// Other methods, like Save, ExportNDJSON and the callbacks, see the encoded
+// values. The validator sees the values before they are encoded.
+func WithValueCodecs(codecs ...Codec) Option {
+	return func(c *Cache) {
+		c.valueCodecs = codecs
+	}
+}
+
+// encodeVal encodes the value with the value codecs. It returns the value as
+// is if there are no value codecs.
+func (c *Cache) encodeVal(val interface{}) (interface{}, error) {
+	if len(c.valueCodecs) == 0 {
+		return val, nil
+	}
+	b, ok := val.([]byte)
+	if !ok {
+		return nil, errNotBytes
+	}
+	var buf bytes.Buffer
+	enc, err := encodeWriter(&buf, c.valueCodecs)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := enc.Write(b); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeVal decodes the value encoded by encodeVal.
+func (c *Cache) decodeVal(val interface{}) (interface{}, error) {
+	if len(c.valueCodecs) == 0 {
+		return val, nil
+	}
+	b, ok := val.([]byte)
+	if !ok {
+		return nil, errNotBytes
+	}
+	dec, err := decodeReader(bytes.NewReader(b), c.valueCodecs)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(dec)
+}
+
+// decoded decodes the found value. It reports the value as not found if it
+// can't be decoded.
+func (c *Cache) decoded(val interface{}, found bool) (interface{}, bool) {
+	if !found {
+		return val, false
+	}
+	val, err := c.decodeVal(val)
+	if err != nil {
+		return nil, false
+	}
+	return val, true
+}
+
+// checksumCodec appends a CRC-32 checksum to data.
+type checksumCodec struct{}
+
+// ChecksumCodec returns a Codec that appends a CRC-32 checksum to data and
+// verifies it when decoding, to detect corruption.
+func ChecksumCodec() Codec {
+	return checksumCodec{}
+}
+
+// Encoder returns a writer that appends the checksum of the written data on
+// Close.
+func (checksumCodec) Encoder(w io.Writer) (io.WriteCloser, error) {
+	return &checksumWriter{w: w, h: crc32.NewIEEE()}, nil
+}
+
+// Decoder reads all data from r and verifies its checksum.
+func (checksumCodec) Decoder(r io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < crc32.Size {
+		return nil, errChecksum
+	}
+	data, sum := data[:len(data)-crc32.Size], data[len(data)-crc32.Size:]
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(sum) {
+		return nil, errChecksum
+	}
+	return bytes.NewReader(data), nil
+}
+
+// checksumWriter writes data through and appends its checksum on Close.
+type checksumWriter struct {
+	w io.Writer
+	h hash.Hash32
+}
+
+// Write writes p and adds it to the checksum.
+func (s *checksumWriter) Write(p []byte) (int, error) {
+	s.h.Write(p)
+	return s.w.Write(p)
+}
+
+// Close writes the checksum.
+func (s *checksumWriter) Close() error {
+	_, err := s.w.Write(s.h.Sum(nil))
+	return err
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithValueCodecs(t *testing.T) {
+	aesCodec, err := NewAESCodec(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	tests := []struct {
+		name    string
+		codecs  []Codec
+		val     any
+		wantErr error
+	}{
+		{
+			name: "stores values as is without codecs",
+			val:  v,
+		},
+		{
+			name:   "round trips compressed, encrypted and checksummed values",
+			codecs: []Codec{GzipCodec(), aesCodec, ChecksumCodec()},
+			val:    []byte(v),
+		},
+		{
+			name:    "rejects values which are not bytes",
+			codecs:  []Codec{ChecksumCodec()},
+			val:     v,
+			wantErr: errNotBytes,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(3, WithValueCodecs(tt.codecs...))
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if err := c.Add(k, tt.val, 0); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error, got %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got, _ := c.Get(k); !reflect.DeepEqual(got, tt.val) {
+				t.Errorf("unexpected Get value, got %v, want %v", got, tt.val)
+			}
+			if got, _ := c.Peek(k); !reflect.DeepEqual(got, tt.val) {
+				t.Errorf("unexpected Peek value, got %v, want %v", got, tt.val)
+			}
+			if got := c.ToMap()[k]; !reflect.DeepEqual(got, tt.val) {
+				t.Errorf("unexpected ToMap value, got %v, want %v", got, tt.val)
+			}
+			_, stored, _ := c.RemoveOldest()
+			if !reflect.DeepEqual(stored, tt.val) {
+				t.Errorf("unexpected RemoveOldest value, got %v, want %v", stored, tt.val)
+			}
+		})
+	}
+}
+
+func TestWithValueCodecs_Corrupt(t *testing.T) {
+	c, err := New(3, WithValueCodecs(ChecksumCodec()))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	addItems(t, c, [][]any{{k, []byte(v)}})
+	e, _ := c.lookup(k)
+	item := mustItem(e)
+	item.Val.([]byte)[0] ^= 0xff
+	if _, found := c.Get(k); found {
+		t.Errorf("expected corrupt value to be reported as not found")
+	}
+	if _, found := c.Peek(k); found {
+		t.Errorf("expected corrupt value to be reported as not found by Peek")
+	}
+}